@@ -0,0 +1,19 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+)
+
+// ShortenSleeps shortens sleeps between requests to Discord until the end of the test.
+func ShortenSleeps(t *testing.T) {
+	t.Helper()
+
+	sleepAttachment, sleepMessages := sleepBetweenAttachmentDownload, sleepBetweenMessagesFetched
+
+	sleepBetweenAttachmentDownload, sleepBetweenMessagesFetched = time.Millisecond, time.Millisecond
+
+	t.Cleanup(func() {
+		sleepBetweenAttachmentDownload, sleepBetweenMessagesFetched = sleepAttachment, sleepMessages
+	})
+}
@@ -2,33 +2,52 @@
 package exporter
 
 import (
+	"context"
 	"database/sql"
 	"os"
 	"path"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/rs/zerolog/log"
 )
 
 const (
-	modeOnce string = "once"
+	modeOnce      string = "once"
+	modeLive      string = "live"
+	modeScheduled string = "scheduled"
+
+	folderAttachments string = "attachments"
 )
 
 // Configuration contains exporter parameters.
+// Mode "once" exports the guild and stops, "live" exports the guild then saves changes from Discord events until the bot stops.
+// Mode "scheduled" exports the guild in background on Schedule, an interval like "24h" or a cron expression like "0 3 * * *".
 type Configuration struct {
 	Mode             string   `json:"mode"`
 	OutputPath       string   `json:"output_path"`
 	DatabaseFilename string   `json:"database_filename"`
 	ChannelsIncluded []string `json:"channels_included"`
 	ChannelsExcluded []string `json:"channels_excluded"`
+	Schedule         string   `json:"schedule"`
 }
 
 // Manager is a struct.
 type Manager struct {
 	files                 map[string]struct{}
+	filesMutex            sync.Mutex
+	removeHandlers        []func()
+	filesDownloaded       atomic.Int64
+	exportMutex           sync.Mutex
+	exports               sync.WaitGroup
+	schedule              schedule
+	scheduleValue         string
+	cancelSchedule        context.CancelFunc
+	scheduleStopped       chan struct{}
 	db                    *sql.DB
 	discordSession        *discordgo.Session
 	guildName             string
@@ -101,9 +120,9 @@ func NewExporterManager(
 
 //nolint:funlen
 func (m *Manager) hasValidConfigurationInFile(config Configuration) bool {
-	if !slices.Contains([]string{modeOnce}, config.Mode) {
+	if !slices.Contains([]string{modeOnce, modeLive, modeScheduled}, config.Mode) {
 		log.Error().
-			Str("help", "Accepted values are 'once'").
+			Str("help", "Accepted values are 'once', 'live', 'scheduled'").
 			Msg("discord_bot.exporter.configuration_invalid_mode")
 
 		return false
@@ -115,6 +134,10 @@ func (m *Manager) hasValidConfigurationInFile(config Configuration) bool {
 		Str("mode", m.mode).
 		Msg("discord_bot.exporter.set_mode")
 
+	if !m.hasValidScheduleInFile(config) {
+		return false
+	}
+
 	outputPath := strings.TrimSpace(config.OutputPath)
 	if outputPath == "" {
 		outputPath = "./exports"
@@ -139,7 +162,7 @@ func (m *Manager) hasValidConfigurationInFile(config Configuration) bool {
 		Str("output_path", m.outputPath).
 		Msg("discord_bot.exporter.set_output_path")
 
-	m.outputPathAttachments = path.Join(m.outputPath, "attachments")
+	m.outputPathAttachments = path.Join(m.outputPath, folderAttachments)
 
 	log.Info().
 		Str("output_path_attachments", m.outputPathAttachments).
@@ -206,6 +229,40 @@ func (m *Manager) hasValidConfigurationInFile(config Configuration) bool {
 	return true
 }
 
+func (m *Manager) hasValidScheduleInFile(config Configuration) bool {
+	m.scheduleValue = strings.TrimSpace(config.Schedule)
+
+	if m.mode != modeScheduled {
+		if m.scheduleValue != "" {
+			log.Error().
+				Str("help", "schedule is only used with mode 'scheduled'").
+				Msg("discord_bot.exporter.configuration_unused_schedule")
+
+			return false
+		}
+
+		return true
+	}
+
+	var err error
+
+	m.schedule, err = parseSchedule(m.scheduleValue)
+	if err != nil {
+		log.Error().Err(err).
+			Str("schedule", m.scheduleValue).
+			Str("help", "Accepted values are intervals like '24h' or cron expressions like '0 3 * * *'").
+			Msg("discord_bot.exporter.configuration_invalid_schedule")
+
+		return false
+	}
+
+	log.Info().
+		Str("schedule", m.scheduleValue).
+		Msg("discord_bot.exporter.set_schedule")
+
+	return true
+}
+
 func createFolders(outputPathAttachments string, outputPathUsers string) bool {
 	log.Info().
 		Str("output_attachments_path", outputPathAttachments).
@@ -0,0 +1,181 @@
+//nolint:paralleltest
+package exporter_test
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/exporter"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:funlen
+func TestRun_Attachments(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unavailable.png" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("content of " + r.URL.Path))
+	}))
+	t.Cleanup(server.Close)
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", GuildID: "guild-123", Name: "general"}},
+	})
+	require.NoError(t, err)
+
+	outputPath := t.TempDir()
+
+	exporter.ShortenSleeps(t)
+
+	exporterManager := exporter.NewExporterManager(exporter.Configuration{
+		Mode:       "live",
+		OutputPath: outputPath,
+	}, guildName, session)
+	require.NotNil(t, exporterManager)
+
+	t.Cleanup(exporterManager.Stop)
+
+	db, err := sql.Open("sqlite3", path.Join(outputPath, "discord.db"))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	message := createMessage("1001")
+	message.GuildID = "guild-123"
+	message.Attachments = []*discordgo.MessageAttachment{
+		{ID: "attachment-1", URL: server.URL + "/available.png", Filename: "available.png", ContentType: "image/png", Size: 100, Width: 10, Height: 20},
+		{ID: "attachment-2", URL: server.URL + "/unavailable.png", Filename: "unavailable.png", ContentType: "image/png", Size: 200},
+	}
+
+	t.Run("should save attachments with the status of their download", func(t *testing.T) {
+		exporterManager.OnMessageCreate(session, &discordgo.MessageCreate{Message: message})
+
+		//nolint:lll
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM attachments WHERE id = ? AND message_id = ? AND filename = ? AND content_type = ? AND size = ? AND width = ? AND height = ? AND url = ? AND local_path = ? AND status = ?`,
+			"attachment-1", "1001", "available.png", "image/png", 100, 10, 20, server.URL+"/available.png", "attachments/attachment-1_available.png", "downloaded"))
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM attachments WHERE id = ? AND local_path = ? AND status = ? AND attempts = ?`,
+			"attachment-2", "attachments/attachment-2_unavailable.png", "failed", 1))
+
+		require.FileExists(t, path.Join(outputPath, "attachments", "attachment-1_available.png"))
+	})
+
+	t.Run("should download failed attachments again with a refreshed URL and keep original URL on next export", func(t *testing.T) {
+		refreshedMessage := createMessage("1001")
+		refreshedMessage.Attachments = []*discordgo.MessageAttachment{
+			{ID: "attachment-1", URL: server.URL + "/available.png", Filename: "available.png"},
+			{ID: "attachment-2", URL: server.URL + "/refreshed.png", Filename: "unavailable.png"},
+		}
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, refreshedMessage),
+				createJSONResponse(t, discordgo.ThreadsList{}),
+				createJSONResponse(t, []*discordgo.Message{}),
+				createJSONResponse(t, discordgo.ThreadsList{}),
+				createJSONResponse(t, discordgo.ThreadsList{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/1001"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/guilds/guild-123/threads/active"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/public?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/private?limit=100"},
+			},
+		)
+
+		exporterManager.Run()
+
+		require.Equal(t, 0, countRows(t, db, `SELECT COUNT(*) FROM attachments WHERE status = ?`, "failed"))
+		require.Equal(t, 2, countRows(t, db, `SELECT COUNT(*) FROM attachments WHERE status = ?`, "downloaded"))
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM attachments WHERE id = ? AND url = ?`, "attachment-2", server.URL+"/unavailable.png"))
+
+		content, err := os.ReadFile(path.Join(outputPath, "attachments", "attachment-2_unavailable.png"))
+		require.NoError(t, err)
+		require.Equal(t, "content of /refreshed.png", string(content))
+	})
+
+	t.Run("should stop downloading failed attachments after max attempts", func(t *testing.T) {
+		_, err := db.ExecContext(t.Context(), `UPDATE attachments SET status = ?, attempts = ? WHERE id = ?`, "failed", 4, "attachment-2")
+		require.NoError(t, err)
+
+		expiredMessage := createMessage("1001")
+		expiredMessage.Attachments = []*discordgo.MessageAttachment{
+			{ID: "attachment-2", URL: server.URL + "/unavailable.png", Filename: "unavailable.png"},
+		}
+
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, expiredMessage),
+				createJSONResponse(t, discordgo.ThreadsList{}),
+				createJSONResponse(t, []*discordgo.Message{}),
+				createJSONResponse(t, discordgo.ThreadsList{}),
+				createJSONResponse(t, discordgo.ThreadsList{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/1001"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/guilds/guild-123/threads/active"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/public?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/private?limit=100"},
+			},
+		)
+
+		exporterManager.Run()
+
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM attachments WHERE id = ? AND status = ? AND attempts = ?`, "attachment-2", "failed", 5))
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.Contains(t, parts, `{"level":"warn","id":"attachment-2","attempts":5,"message":"discord_bot.exporter.attachment_download_abandoned"}`)
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, discordgo.ThreadsList{}),
+				createJSONResponse(t, []*discordgo.Message{}),
+				createJSONResponse(t, discordgo.ThreadsList{}),
+				createJSONResponse(t, discordgo.ThreadsList{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/guilds/guild-123/threads/active"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/public?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/private?limit=100"},
+			},
+		)
+
+		exporterManager.Run()
+
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM attachments WHERE id = ? AND status = ? AND attempts = ?`, "attachment-2", "failed", 5))
+	})
+
+	t.Run("should keep attachments of message deleted", func(t *testing.T) {
+		exporterManager.OnMessageDelete(session, &discordgo.MessageDelete{Message: &discordgo.Message{ID: "1001", ChannelID: "channel-123", GuildID: "guild-123"}})
+
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM messages WHERE id = ? AND deleted_at IS NOT NULL`, "1001"))
+		require.Equal(t, 2, countRows(t, db, `SELECT COUNT(*) FROM attachments WHERE message_id = ?`, "1001"))
+	})
+}
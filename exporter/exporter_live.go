@@ -0,0 +1,280 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+func (m *Manager) addHandlers() {
+	log.Info().
+		Msg("discord_bot.exporter.add_handler_on_message_create")
+
+	m.removeHandlers = append(m.removeHandlers, m.discordSession.AddHandler(m.OnMessageCreate))
+
+	log.Info().
+		Msg("discord_bot.exporter.add_handler_on_message_update")
+
+	m.removeHandlers = append(m.removeHandlers, m.discordSession.AddHandler(m.OnMessageUpdate))
+
+	log.Info().
+		Msg("discord_bot.exporter.add_handler_on_message_delete")
+
+	m.removeHandlers = append(m.removeHandlers, m.discordSession.AddHandler(m.OnMessageDelete))
+
+	log.Info().
+		Msg("discord_bot.exporter.add_handler_on_channel_create")
+
+	m.removeHandlers = append(m.removeHandlers, m.discordSession.AddHandler(m.OnChannelCreate))
+
+	log.Info().
+		Msg("discord_bot.exporter.add_handler_on_channel_update")
+
+	m.removeHandlers = append(m.removeHandlers, m.discordSession.AddHandler(m.OnChannelUpdate))
+
+	log.Info().
+		Msg("discord_bot.exporter.add_handler_on_channel_delete")
+
+	m.removeHandlers = append(m.removeHandlers, m.discordSession.AddHandler(m.OnChannelDelete))
+
+	log.Info().
+		Msg("discord_bot.exporter.add_handler_on_thread_create")
+
+	m.removeHandlers = append(m.removeHandlers, m.discordSession.AddHandler(m.OnThreadCreate))
+
+	log.Info().
+		Msg("discord_bot.exporter.add_handler_on_thread_update")
+
+	m.removeHandlers = append(m.removeHandlers, m.discordSession.AddHandler(m.OnThreadUpdate))
+
+	log.Info().
+		Msg("discord_bot.exporter.add_handler_on_thread_delete")
+
+	m.removeHandlers = append(m.removeHandlers, m.discordSession.AddHandler(m.OnThreadDelete))
+
+	log.Info().
+		Msg("discord_bot.exporter.add_handler_on_guild_update")
+
+	m.removeHandlers = append(m.removeHandlers, m.discordSession.AddHandler(m.OnGuildUpdate))
+}
+
+// Stop stops exports of scheduled mode, removes handlers of live mode and closes the database.
+func (m *Manager) Stop() {
+	log.Info().
+		Msg("discord_bot.exporter.stopping")
+
+	m.stopSchedule()
+
+	for _, removeHandler := range m.removeHandlers {
+		removeHandler()
+	}
+
+	m.removeHandlers = nil
+
+	err := m.db.Close()
+	if err != nil {
+		log.Error().Err(err).
+			Msg("discord_bot.exporter.database_closing_failed")
+
+		return
+	}
+
+	log.Info().
+		Msg("discord_bot.exporter.database_closed")
+}
+
+// isChannelOfMessageExported finds channel in state, events of messages only have the ID of the channel.
+// Messages of threads are exported when the parent channel is exported.
+func (m *Manager) isChannelOfMessageExported(channelID string) bool {
+	channel, err := m.discordSession.State.Channel(channelID)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("channel_id", channelID).
+			Msg("discord_bot.exporter.channel_not_found")
+
+		return false
+	}
+
+	return m.isThreadOrChannelExported(channel)
+}
+
+// OnMessageCreate is public for tests, never call it directly
+func (m *Manager) OnMessageCreate(_ *discordgo.Session, event *discordgo.MessageCreate) {
+	log.Debug().
+		Msg("discord_bot.exporter.event_message_create_received")
+
+	if event == nil || event.Message == nil || event.Author == nil || event.GuildID == "" {
+		return
+	}
+
+	if !m.isChannelOfMessageExported(event.ChannelID) {
+		return
+	}
+
+	m.saveMessage(context.Background(), event.Message, event.GuildID)
+}
+
+// OnMessageUpdate is public for tests, never call it directly
+// Updates of embeds come without author, the full message is fetched in this case.
+func (m *Manager) OnMessageUpdate(_ *discordgo.Session, event *discordgo.MessageUpdate) {
+	log.Debug().
+		Msg("discord_bot.exporter.event_message_update_received")
+
+	if event == nil || event.Message == nil || event.GuildID == "" {
+		return
+	}
+
+	if !m.isChannelOfMessageExported(event.ChannelID) {
+		return
+	}
+
+	message := event.Message
+	if message.Author == nil {
+		var err error
+
+		message, err = m.discordSession.ChannelMessage(event.ChannelID, event.ID)
+		if err != nil {
+			log.Error().Err(err).
+				Str("channel_id", event.ChannelID).
+				Str("message_id", event.ID).
+				Msg("discord_bot.exporter.message_fetching_failed")
+
+			return
+		}
+	}
+
+	m.saveMessage(context.Background(), message, event.GuildID)
+}
+
+// OnMessageDelete is public for tests, never call it directly
+// The message and its attachments are kept, marked as deleted.
+func (m *Manager) OnMessageDelete(_ *discordgo.Session, event *discordgo.MessageDelete) {
+	log.Debug().
+		Msg("discord_bot.exporter.event_message_delete_received")
+
+	if event == nil || event.Message == nil || event.GuildID == "" {
+		return
+	}
+
+	m.markMessageDeleted(context.Background(), event.ID, time.Now().UTC().Format(time.DateTime))
+}
+
+// OnChannelCreate is public for tests, never call it directly
+func (m *Manager) OnChannelCreate(_ *discordgo.Session, event *discordgo.ChannelCreate) {
+	log.Debug().
+		Msg("discord_bot.exporter.event_channel_create_received")
+
+	if event == nil || event.Channel == nil || event.GuildID == "" {
+		return
+	}
+
+	if !m.isChannelExported(event.Channel) {
+		return
+	}
+
+	m.addOrUpdateChannel(context.Background(), translateChannel(event.Channel))
+}
+
+// OnChannelUpdate is public for tests, never call it directly
+func (m *Manager) OnChannelUpdate(_ *discordgo.Session, event *discordgo.ChannelUpdate) {
+	log.Debug().
+		Msg("discord_bot.exporter.event_channel_update_received")
+
+	if event == nil || event.Channel == nil || event.GuildID == "" {
+		return
+	}
+
+	if !m.isChannelExported(event.Channel) {
+		return
+	}
+
+	m.addOrUpdateChannel(context.Background(), translateChannel(event.Channel))
+}
+
+// OnChannelDelete is public for tests, never call it directly
+// The channel and its messages are kept, marked as deleted.
+func (m *Manager) OnChannelDelete(_ *discordgo.Session, event *discordgo.ChannelDelete) {
+	log.Debug().
+		Msg("discord_bot.exporter.event_channel_delete_received")
+
+	if event == nil || event.Channel == nil || event.GuildID == "" {
+		return
+	}
+
+	ctx := context.Background()
+	deletedAt := time.Now().UTC().Format(time.DateTime)
+
+	marked := m.markMessagesOfChannelDeleted(ctx, event.ID, deletedAt)
+	if !marked {
+		return
+	}
+
+	m.markChannelDeleted(ctx, event.ID, deletedAt)
+}
+
+// OnThreadCreate is public for tests, never call it directly
+func (m *Manager) OnThreadCreate(_ *discordgo.Session, event *discordgo.ThreadCreate) {
+	log.Debug().
+		Msg("discord_bot.exporter.event_thread_create_received")
+
+	if event == nil || event.Channel == nil || event.GuildID == "" {
+		return
+	}
+
+	if !m.isThreadOrChannelExported(event.Channel) {
+		return
+	}
+
+	m.addOrUpdateChannel(context.Background(), translateChannel(event.Channel))
+}
+
+// OnThreadUpdate is public for tests, never call it directly
+func (m *Manager) OnThreadUpdate(_ *discordgo.Session, event *discordgo.ThreadUpdate) {
+	log.Debug().
+		Msg("discord_bot.exporter.event_thread_update_received")
+
+	if event == nil || event.Channel == nil || event.GuildID == "" {
+		return
+	}
+
+	if !m.isThreadOrChannelExported(event.Channel) {
+		return
+	}
+
+	m.addOrUpdateChannel(context.Background(), translateChannel(event.Channel))
+}
+
+// OnThreadDelete is public for tests, never call it directly
+// The thread and its messages are kept, marked as deleted.
+func (m *Manager) OnThreadDelete(_ *discordgo.Session, event *discordgo.ThreadDelete) {
+	log.Debug().
+		Msg("discord_bot.exporter.event_thread_delete_received")
+
+	if event == nil || event.Channel == nil || event.GuildID == "" {
+		return
+	}
+
+	ctx := context.Background()
+	deletedAt := time.Now().UTC().Format(time.DateTime)
+
+	marked := m.markMessagesOfChannelDeleted(ctx, event.ID, deletedAt)
+	if !marked {
+		return
+	}
+
+	m.markChannelDeleted(ctx, event.ID, deletedAt)
+}
+
+// OnGuildUpdate is public for tests, never call it directly
+func (m *Manager) OnGuildUpdate(_ *discordgo.Session, event *discordgo.GuildUpdate) {
+	log.Debug().
+		Msg("discord_bot.exporter.event_guild_update_received")
+
+	if event == nil || event.Guild == nil {
+		return
+	}
+
+	m.saveGuild(context.Background(), event.Guild)
+}
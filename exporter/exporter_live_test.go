@@ -0,0 +1,239 @@
+//nolint:paralleltest
+package exporter_test
+
+import (
+	"bytes"
+	"database/sql"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blueprintue/discord-bot/exporter"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func countRows(t *testing.T, db *sql.DB, query string, args ...any) int {
+	t.Helper()
+
+	var count int
+
+	err := db.QueryRowContext(t.Context(), query, args...).Scan(&count)
+	require.NoError(t, err)
+
+	return count
+}
+
+func TestRun_Live(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	exporterManager := exporter.NewExporterManager(exporter.Configuration{
+		Mode:       "live",
+		OutputPath: t.TempDir(),
+	}, guildName, session)
+	require.NotNil(t, exporterManager)
+
+	bufferLogs.Reset()
+
+	exporterManager.Run()
+	exporterManager.Stop()
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.starting"}`, parts[0])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.add_handler_on_message_create"}`, parts[1])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.add_handler_on_message_update"}`, parts[2])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.add_handler_on_message_delete"}`, parts[3])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.add_handler_on_channel_create"}`, parts[4])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.add_handler_on_channel_update"}`, parts[5])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.add_handler_on_channel_delete"}`, parts[6])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.add_handler_on_thread_create"}`, parts[7])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.add_handler_on_thread_update"}`, parts[8])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.add_handler_on_thread_delete"}`, parts[9])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.add_handler_on_guild_update"}`, parts[10])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.export_starting"}`, parts[11])
+	require.JSONEq(t, `{"level":"info","count_new_guilds":0,"count_new_channels":0,"count_new_messages":0,"count_new_files":0,"is_canceled":false,"message":"discord_bot.exporter.export_done"}`, parts[12])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.backfill_done"}`, parts[13])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.stopping"}`, parts[14])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.database_closed"}`, parts[15])
+	require.Empty(t, parts[16])
+}
+
+//nolint:funlen
+func TestHandlers_Live(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", GuildID: "guild-123", Name: "general"}, {ID: "channel-456", GuildID: "guild-123", Name: "secret"}},
+	})
+	require.NoError(t, err)
+
+	outputPath := t.TempDir()
+
+	exporterManager := exporter.NewExporterManager(exporter.Configuration{
+		Mode:             "live",
+		OutputPath:       outputPath,
+		ChannelsExcluded: []string{"secret"},
+	}, guildName, session)
+	require.NotNil(t, exporterManager)
+
+	t.Cleanup(exporterManager.Stop)
+
+	db, err := sql.Open("sqlite3", path.Join(outputPath, "discord.db"))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	newMessage := func(id string, channelID string, content string) *discordgo.Message {
+		return &discordgo.Message{
+			ID:        id,
+			ChannelID: channelID,
+			GuildID:   "guild-123",
+			Content:   content,
+			Timestamp: time.Date(2024, 1, 31, 18, 0, 0, 0, time.UTC),
+			Author:    &discordgo.User{ID: "user-123", Username: "user"},
+		}
+	}
+
+	t.Run("should save message created in exported channel", func(t *testing.T) {
+		exporterManager.OnMessageCreate(session, &discordgo.MessageCreate{Message: newMessage("message-1", "channel-123", "hello")})
+
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM messages WHERE id = ? AND content = ?`, "message-1", "hello"))
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM users WHERE id = ?`, "user-123"))
+	})
+
+	t.Run("should skip message created in excluded channel", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		exporterManager.OnMessageCreate(session, &discordgo.MessageCreate{Message: newMessage("message-2", "channel-456", "hidden")})
+
+		require.Equal(t, 0, countRows(t, db, `SELECT COUNT(*) FROM messages WHERE id = ?`, "message-2"))
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","channel":"secret","rule":"channels_excluded","message":"discord_bot.exporter.skip_channel"}`, parts[1])
+	})
+
+	t.Run("should save message updated", func(t *testing.T) {
+		exporterManager.OnMessageUpdate(session, &discordgo.MessageUpdate{Message: newMessage("message-1", "channel-123", "hello world")})
+
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM messages WHERE id = ? AND content = ?`, "message-1", "hello world"))
+	})
+
+	t.Run("should mark message deleted", func(t *testing.T) {
+		exporterManager.OnMessageDelete(session, &discordgo.MessageDelete{Message: &discordgo.Message{ID: "message-1", ChannelID: "channel-123", GuildID: "guild-123"}})
+
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM messages WHERE id = ? AND content = ? AND deleted_at IS NOT NULL`, "message-1", "hello world"))
+	})
+
+	t.Run("should save channel created and updated", func(t *testing.T) {
+		exporterManager.OnChannelCreate(session, &discordgo.ChannelCreate{Channel: &discordgo.Channel{ID: "channel-789", GuildID: "guild-123", Name: "news"}})
+		exporterManager.OnChannelUpdate(session, &discordgo.ChannelUpdate{Channel: &discordgo.Channel{ID: "channel-789", GuildID: "guild-123", Name: "announcements"}})
+
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM channels WHERE id = ? AND name = ?`, "channel-789", "announcements"))
+	})
+
+	t.Run("should skip channel created with excluded name", func(t *testing.T) {
+		exporterManager.OnChannelCreate(session, &discordgo.ChannelCreate{Channel: &discordgo.Channel{ID: "channel-999", GuildID: "guild-123", Name: "secret"}})
+
+		require.Equal(t, 0, countRows(t, db, `SELECT COUNT(*) FROM channels WHERE id = ?`, "channel-999"))
+	})
+
+	t.Run("should mark channel deleted with its messages", func(t *testing.T) {
+		exporterManager.OnMessageCreate(session, &discordgo.MessageCreate{Message: newMessage("message-3", "channel-123", "bye")})
+		exporterManager.OnChannelCreate(session, &discordgo.ChannelCreate{Channel: &discordgo.Channel{ID: "channel-123", GuildID: "guild-123", Name: "general"}})
+
+		exporterManager.OnChannelDelete(session, &discordgo.ChannelDelete{Channel: &discordgo.Channel{ID: "channel-123", GuildID: "guild-123", Name: "general"}})
+
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM channels WHERE id = ? AND deleted_at IS NOT NULL`, "channel-123"))
+		require.Equal(t, 2, countRows(t, db, `SELECT COUNT(*) FROM messages WHERE channel_id = ? AND deleted_at IS NOT NULL`, "channel-123"))
+	})
+
+	t.Run("should save thread created and its messages", func(t *testing.T) {
+		thread := &discordgo.Channel{ID: "thread-123", GuildID: "guild-123", ParentID: "channel-456", Name: "public", Type: discordgo.ChannelTypeGuildPublicThread}
+
+		require.NoError(t, session.State.ChannelAdd(thread))
+
+		exporterManager.OnThreadCreate(session, &discordgo.ThreadCreate{Channel: thread})
+		exporterManager.OnMessageCreate(session, &discordgo.MessageCreate{Message: newMessage("message-4", "thread-123", "in thread")})
+
+		require.Equal(t, 0, countRows(t, db, `SELECT COUNT(*) FROM channels WHERE id = ?`, "thread-123"))
+		require.Equal(t, 0, countRows(t, db, `SELECT COUNT(*) FROM messages WHERE id = ?`, "message-4"))
+
+		thread = &discordgo.Channel{ID: "thread-456", GuildID: "guild-123", ParentID: "channel-789", Name: "secret", Type: discordgo.ChannelTypeGuildPublicThread}
+
+		require.NoError(t, session.State.ChannelAdd(&discordgo.Channel{ID: "channel-789", GuildID: "guild-123", Name: "announcements"}))
+		require.NoError(t, session.State.ChannelAdd(thread))
+
+		exporterManager.OnThreadCreate(session, &discordgo.ThreadCreate{Channel: thread})
+		exporterManager.OnMessageCreate(session, &discordgo.MessageCreate{Message: newMessage("message-5", "thread-456", "in thread")})
+
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM channels WHERE id = ? AND parent_id = ?`, "thread-456", "channel-789"))
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM messages WHERE id = ?`, "message-5"))
+
+		exporterManager.OnThreadDelete(session, &discordgo.ThreadDelete{Channel: thread})
+
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM channels WHERE id = ? AND deleted_at IS NOT NULL`, "thread-456"))
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM messages WHERE channel_id = ? AND deleted_at IS NOT NULL`, "thread-456"))
+	})
+
+	t.Run("should save guild updated", func(t *testing.T) {
+		exporterManager.OnGuildUpdate(session, &discordgo.GuildUpdate{Guild: &discordgo.Guild{ID: "guild-123", Name: "new name"}})
+
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM guilds WHERE id = ? AND name = ?`, "guild-123", "new name"))
+	})
+}
+
+func TestHandlers_LiveWithDatabaseWithoutDeletedAt(t *testing.T) {
+	log.Logger = zerolog.Nop()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", GuildID: "guild-123", Name: "general"}},
+	})
+	require.NoError(t, err)
+
+	outputPath := t.TempDir()
+
+	db, err := sql.Open("sqlite3", path.Join(outputPath, "discord.db"))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	_, err = db.Exec(`CREATE TABLE "channels" (id VARCHAR (31) PRIMARY KEY, guild_id VARCHAR (255) NOT NULL, name VARCHAR (255) NOT NULL, topic TEXT NULL, type VARCHAR (255) NOT NULL, position INTEGER NOT NULL, parent_id VARCHAR (255) NULL, owner_id VARCHAR (255) NOT NULL);
+	CREATE TABLE "messages" (id VARCHAR (31) PRIMARY KEY, guild_id VARCHAR (255) NOT NULL, channel_id VARCHAR (255) NOT NULL, author_id VARCHAR (255) NOT NULL, content TEXT NOT NULL, sent_at VARCHAR (255) NOT NULL, is_embed INTEGER);
+	INSERT INTO channels VALUES ('channel-123', 'guild-123', 'general', '', 'guild_text', 0, '', '');
+	INSERT INTO messages VALUES ('message-1', 'guild-123', 'channel-123', 'user-123', 'hello', '2024-01-31 18:00:00', 0);`)
+	require.NoError(t, err)
+
+	exporterManager := exporter.NewExporterManager(exporter.Configuration{
+		Mode:       "live",
+		OutputPath: outputPath,
+	}, guildName, session)
+	require.NotNil(t, exporterManager)
+
+	t.Cleanup(exporterManager.Stop)
+
+	exporterManager.OnChannelDelete(session, &discordgo.ChannelDelete{Channel: &discordgo.Channel{ID: "channel-123", GuildID: "guild-123", Name: "general"}})
+
+	require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM channels WHERE id = ? AND deleted_at IS NOT NULL`, "channel-123"))
+	require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM messages WHERE id = ? AND deleted_at IS NOT NULL`, "message-1"))
+}
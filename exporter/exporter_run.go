@@ -8,54 +8,92 @@ import (
 	"path"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/rs/zerolog/log"
 )
 
 const (
-	limitChannelMessages           = 100
-	limitLoopFetchChannelMessages  = 100
-	permissionDirectory            = 0o750
+	limitChannelMessages          = 100
+	limitLoopFetchChannelMessages = 100
+	permissionDirectory           = 0o750
+)
+
+// sleeps between requests to Discord, they are variables to be shortened by tests.
+var (
 	sleepBetweenAttachmentDownload = time.Second * 1
 	sleepBetweenMessagesFetched    = time.Second * 5
 )
 
 // Run create sqlite database and export channels/messages/authors and attachments.
+// In live mode, events are saved after the export until Stop is called, handlers are added first to not miss events during the export.
+// In scheduled mode, Run returns immediately and exports are done in background until Stop is called.
 func (m *Manager) Run() {
-	ctx := context.Background()
-
 	log.Info().
 		Msg("discord_bot.exporter.starting")
 
-	hasChannelsIncluded := len(m.channelsIncluded) > 0
+	if m.mode == modeScheduled {
+		m.startSchedule()
+
+		return
+	}
+
+	if m.mode == modeLive {
+		m.addHandlers()
+	}
+
+	m.export(context.Background())
+
+	if m.mode == modeLive {
+		log.Info().
+			Msg("discord_bot.exporter.backfill_done")
+
+		return
+	}
+
+	log.Info().
+		Msg("discord_bot.exporter.stopped")
+}
+
+// export saves guilds, channels with their threads and messages, only one export writes in the database at a time.
+func (m *Manager) export(ctx context.Context) {
+	if !m.exportMutex.TryLock() {
+		log.Warn().
+			Str("help", "The previous export is still running").
+			Msg("discord_bot.exporter.export_skipped")
+
+		return
+	}
+
+	defer m.exportMutex.Unlock()
+
+	log.Info().
+		Msg("discord_bot.exporter.export_starting")
+
+	countsBefore := m.countExported(ctx)
+
+	m.retryFailedAttachments(ctx)
 
 	for _, guild := range m.discordSession.State.Guilds {
-		if guild.Icon != "" {
-			m.downloadFile(guild.IconURL("4096"), path.Join(m.outputPath, "icon_guild_"+guild.ID+".png"))
+		if ctx.Err() != nil {
+			break
 		}
 
-		saved := m.addOrUpdateGuild(ctx, translateGuild(guild))
+		saved := m.saveGuild(ctx, guild)
 		if !saved {
 			continue
 		}
 
-		for idxChannel := range guild.Channels {
-			if slices.Contains(m.channelsExcluded, guild.Channels[idxChannel].Name) {
-				log.Info().
-					Str("channel", guild.Channels[idxChannel].Name).
-					Str("rule", "channels_excluded").
-					Msg("discord_bot.exporter.skip_channel")
+		activeThreads := m.fetchActiveThreads(guild.ID)
 
-				continue
+		for idxChannel := range guild.Channels {
+			if ctx.Err() != nil {
+				break
 			}
 
-			if hasChannelsIncluded && !slices.Contains(m.channelsIncluded, guild.Channels[idxChannel].Name) {
-				log.Info().
-					Str("channel", guild.Channels[idxChannel].Name).
-					Str("rule", "channels_included").
-					Msg("discord_bot.exporter.skip_channel")
-
+			if !m.isChannelExported(guild.Channels[idxChannel]) {
 				continue
 			}
 
@@ -64,51 +102,129 @@ func (m *Manager) Run() {
 				continue
 			}
 
-			m.fetchMessagesFromChannel(ctx, guild.ID, guild.Channels[idxChannel].ID, "")
+			if hasMessages(guild.Channels[idxChannel]) {
+				m.fetchMessagesFromChannel(ctx, guild.ID, guild.Channels[idxChannel].ID)
+			}
+
+			m.exportThreadsOfChannel(ctx, guild.ID, guild.Channels[idxChannel], activeThreads[guild.Channels[idxChannel].ID])
 		}
 	}
 
+	countsAfter := m.countExported(context.WithoutCancel(ctx))
+
 	log.Info().
-		Msg("discord_bot.exporter.stopped")
+		Int("count_new_guilds", countsAfter.guilds-countsBefore.guilds).
+		Int("count_new_channels", countsAfter.channels-countsBefore.channels).
+		Int("count_new_messages", countsAfter.messages-countsBefore.messages).
+		Int64("count_new_files", countsAfter.files-countsBefore.files).
+		Bool("is_canceled", ctx.Err() != nil).
+		Msg("discord_bot.exporter.export_done")
 }
 
-func (m *Manager) fetchMessagesFromChannel(ctx context.Context, guildID string, channelID string, startingID string) {
-	maxLoops := 0
+// fetchMessagesFromChannel continues the export of channel from its checkpoint.
+// Messages newer than the checkpoint are fetched first, then older messages until the first message of the channel.
+func (m *Manager) fetchMessagesFromChannel(ctx context.Context, guildID string, channelID string) {
+	checkpoint, loaded := m.getCheckpoint(ctx, channelID)
+	if !loaded {
+		return
+	}
+
+	if checkpoint.NewestMessageID != "" {
+		fetched := m.fetchNewerMessagesFromChannel(ctx, guildID, &checkpoint)
+		if !fetched {
+			return
+		}
+	}
+
+	if checkpoint.NewestMessageID == "" || !checkpoint.IsComplete {
+		m.fetchOlderMessagesFromChannel(ctx, guildID, &checkpoint)
+	}
+}
 
-	for {
-		maxLoops++
+// fetchNewerMessagesFromChannel fetches messages sent after the newest message of checkpoint.
+func (m *Manager) fetchNewerMessagesFromChannel(ctx context.Context, guildID string, checkpoint *checkpointStorage) bool {
+	for range limitLoopFetchChannelMessages {
+		if ctx.Err() != nil {
+			return false
+		}
 
-		messages, err := m.discordSession.ChannelMessages(channelID, limitChannelMessages, startingID, "", "")
+		messages, err := m.discordSession.ChannelMessages(checkpoint.ChannelID, limitChannelMessages, "", checkpoint.NewestMessageID, "")
 		if err != nil {
 			log.Error().Err(err).
-				Str("channel_id", channelID).
+				Str("channel_id", checkpoint.ChannelID).
 				Msg("discord_bot.exporter.channel_messages_fetching_failed")
 
-			return
+			return false
 		}
 
-		if len(messages) == limitChannelMessages {
-			startingID = messages[limitChannelMessages-1].ID
-		} else {
-			maxLoops += limitLoopFetchChannelMessages
+		saved := m.saveMessages(ctx, messages, guildID, checkpoint.ChannelID)
+		if !saved {
+			return false
 		}
 
-		for idxMessage := range messages {
-			if messages[idxMessage].Author.Avatar != "" {
-				m.downloadFile(messages[idxMessage].Author.AvatarURL(""), path.Join(m.outputPathUsers, messages[idxMessage].Author.Avatar+".png"))
+		if len(messages) > 0 {
+			checkpoint.NewestMessageID = newestMessageID(messages)
+
+			saved := m.addOrUpdateCheckpoint(ctx, *checkpoint)
+			if !saved {
+				return false
 			}
+		}
+
+		log.Info().
+			Msg("Sleep 5 second - messages")
 
-			m.addOrUpdateUser(ctx, translateUser(messages[idxMessage].Author))
-			m.addOrUpdateMessage(ctx, translateMessage(messages[idxMessage], guildID))
+		time.Sleep(sleepBetweenMessagesFetched)
+
+		if len(messages) < limitChannelMessages {
+			return true
+		}
+	}
 
-			for idxAttachment := range messages[idxMessage].Attachments {
-				//nolint:lll
-				m.downloadFile(messages[idxMessage].Attachments[idxAttachment].URL, path.Join(m.outputPathAttachments, messages[idxMessage].Attachments[idxAttachment].ID+"_"+messages[idxMessage].Attachments[idxAttachment].Filename))
+	log.Warn().
+		Str("channel_id", checkpoint.ChannelID).
+		Str("newest_message_id", checkpoint.NewestMessageID).
+		Str("help", "Run the export again to continue after this message").
+		Msg("discord_bot.exporter.channel_export_paused")
 
-				log.Info().
-					Msg("Sleep 1 second - attachment #" + strconv.Itoa(idxAttachment))
+	return true
+}
 
-				time.Sleep(sleepBetweenAttachmentDownload)
+// fetchOlderMessagesFromChannel fetches messages sent before the oldest message of checkpoint, until the first message of the channel.
+func (m *Manager) fetchOlderMessagesFromChannel(ctx context.Context, guildID string, checkpoint *checkpointStorage) {
+	for range limitLoopFetchChannelMessages {
+		if ctx.Err() != nil {
+			return
+		}
+
+		messages, err := m.discordSession.ChannelMessages(checkpoint.ChannelID, limitChannelMessages, checkpoint.OldestMessageID, "", "")
+		if err != nil {
+			log.Error().Err(err).
+				Str("channel_id", checkpoint.ChannelID).
+				Msg("discord_bot.exporter.channel_messages_fetching_failed")
+
+			return
+		}
+
+		saved := m.saveMessages(ctx, messages, guildID, checkpoint.ChannelID)
+		if !saved {
+			return
+		}
+
+		if len(messages) > 0 {
+			checkpoint.OldestMessageID = oldestMessageID(messages)
+
+			if checkpoint.NewestMessageID == "" {
+				checkpoint.NewestMessageID = newestMessageID(messages)
+			}
+		}
+
+		checkpoint.IsComplete = len(messages) < limitChannelMessages
+
+		if checkpoint.NewestMessageID != "" {
+			saved := m.addOrUpdateCheckpoint(ctx, *checkpoint)
+			if !saved {
+				return
 			}
 		}
 
@@ -117,20 +233,223 @@ func (m *Manager) fetchMessagesFromChannel(ctx context.Context, guildID string,
 
 		time.Sleep(sleepBetweenMessagesFetched)
 
-		if maxLoops >= limitLoopFetchChannelMessages {
+		if checkpoint.IsComplete {
 			return
 		}
 	}
+
+	log.Warn().
+		Str("channel_id", checkpoint.ChannelID).
+		Str("oldest_message_id", checkpoint.OldestMessageID).
+		Str("help", "Run the export again to continue before this message").
+		Msg("discord_bot.exporter.channel_export_paused")
+}
+
+// newestMessageID compares IDs as snowflakes, longer IDs are newer.
+func newestMessageID(messages []*discordgo.Message) string {
+	return slices.MaxFunc(messages, compareMessageIDs).ID
+}
+
+func oldestMessageID(messages []*discordgo.Message) string {
+	return slices.MinFunc(messages, compareMessageIDs).ID
 }
 
-func (m *Manager) downloadFile(url string, filepath string) {
+func compareMessageIDs(a *discordgo.Message, b *discordgo.Message) int {
+	if len(a.ID) != len(b.ID) {
+		return len(a.ID) - len(b.ID)
+	}
+
+	return strings.Compare(a.ID, b.ID)
+}
+
+// saveGuild saves guild and downloads its icon.
+func (m *Manager) saveGuild(ctx context.Context, guild *discordgo.Guild) bool {
+	if guild.Icon != "" {
+		m.downloadFile(guild.IconURL("4096"), path.Join(m.outputPath, "icon_guild_"+guild.ID+".png"))
+	}
+
+	return m.addOrUpdateGuild(ctx, translateGuild(guild))
+}
+
+// saveMessages saves a page of messages, the checkpoint must not move past the page when one of them is not saved.
+func (m *Manager) saveMessages(ctx context.Context, messages []*discordgo.Message, guildID string, channelID string) bool {
+	allSaved := true
+
+	for idxMessage := range messages {
+		saved := m.saveMessage(ctx, messages[idxMessage], guildID)
+		if !saved {
+			allSaved = false
+		}
+	}
+
+	if !allSaved {
+		log.Warn().
+			Str("channel_id", channelID).
+			Str("help", "Run the export again to fetch messages not saved").
+			Msg("discord_bot.exporter.channel_export_stopped")
+	}
+
+	return allSaved
+}
+
+// saveMessage saves message and its author, and downloads avatar of author and attachments.
+func (m *Manager) saveMessage(ctx context.Context, message *discordgo.Message, guildID string) bool {
+	if message.Author.Avatar != "" {
+		m.downloadFile(message.Author.AvatarURL(""), path.Join(m.outputPathUsers, message.Author.Avatar+".png"))
+	}
+
+	m.addOrUpdateUser(ctx, translateUser(message.Author))
+
+	saved := m.addOrUpdateMessage(ctx, translateMessage(message, guildID))
+	if !saved {
+		return false
+	}
+
+	for idxAttachment := range message.Attachments {
+		m.saveAttachment(ctx, message.Attachments[idxAttachment], message.ID)
+
+		log.Info().
+			Msg("Sleep 1 second - attachment #" + strconv.Itoa(idxAttachment))
+
+		time.Sleep(sleepBetweenAttachmentDownload)
+	}
+
+	return true
+}
+
+// saveAttachment downloads attachment and saves it with the status of the download.
+func (m *Manager) saveAttachment(ctx context.Context, attachment *discordgo.MessageAttachment, messageID string) {
+	localPath := path.Join(folderAttachments, attachment.ID+"_"+attachment.Filename)
+
+	attachmentToSave := translateAttachment(attachment, messageID, localPath, attachmentDownloaded)
+	if !m.downloadFile(attachment.URL, path.Join(m.outputPath, localPath)) {
+		attachmentToSave.Status = attachmentFailed
+		attachmentToSave.Attempts = 1
+	}
+
+	m.addOrUpdateAttachment(ctx, attachmentToSave)
+}
+
+// retryFailedAttachments downloads again attachments which failed in previous exports, at most maxAttachmentAttempts times.
+// URLs of attachments are signed and expire, they are refreshed from their message before each download.
+func (m *Manager) retryFailedAttachments(ctx context.Context) {
+	attachments := m.getFailedAttachments(ctx)
+	if len(attachments) == 0 {
+		return
+	}
+
+	log.Info().
+		Int("count_attachments", len(attachments)).
+		Msg("discord_bot.exporter.retrying_failed_attachments")
+
+	for _, attachment := range attachments {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// refreshed URL is only used to download, the stored URL stays the one of the export
+		downloadURL := m.refreshAttachmentURL(attachment)
+
+		if m.downloadFile(downloadURL, path.Join(m.outputPath, attachment.LocalPath)) {
+			attachment.Status = attachmentDownloaded
+		} else {
+			attachment.Attempts++
+
+			if attachment.Attempts >= maxAttachmentAttempts {
+				log.Warn().
+					Str("id", attachment.ID).
+					Int("attempts", attachment.Attempts).
+					Msg("discord_bot.exporter.attachment_download_abandoned")
+			}
+		}
+
+		m.addOrUpdateAttachment(ctx, attachment)
+
+		time.Sleep(sleepBetweenAttachmentDownload)
+	}
+}
+
+// refreshAttachmentURL fetches the message of attachment to get a fresh signed URL, the stored URL is kept when it fails.
+func (m *Manager) refreshAttachmentURL(attachment attachmentStorage) string {
+	message, err := m.discordSession.ChannelMessage(attachment.ChannelID, attachment.MessageID)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("id", attachment.ID).
+			Str("channel_id", attachment.ChannelID).
+			Str("message_id", attachment.MessageID).
+			Msg("discord_bot.exporter.attachment_url_refreshing_failed")
+
+		return attachment.URL
+	}
+
+	for _, messageAttachment := range message.Attachments {
+		if messageAttachment.ID == attachment.ID {
+			return messageAttachment.URL
+		}
+	}
+
+	log.Warn().
+		Str("id", attachment.ID).
+		Str("message_id", attachment.MessageID).
+		Msg("discord_bot.exporter.attachment_not_found_in_message")
+
+	return attachment.URL
+}
+
+// isChannelExported applies channels_excluded then channels_included.
+func (m *Manager) isChannelExported(channel *discordgo.Channel) bool {
+	if slices.Contains(m.channelsExcluded, channel.Name) {
+		log.Info().
+			Str("channel", channel.Name).
+			Str("rule", "channels_excluded").
+			Msg("discord_bot.exporter.skip_channel")
+
+		return false
+	}
+
+	if len(m.channelsIncluded) > 0 && !slices.Contains(m.channelsIncluded, channel.Name) {
+		log.Info().
+			Str("channel", channel.Name).
+			Str("rule", "channels_included").
+			Msg("discord_bot.exporter.skip_channel")
+
+		return false
+	}
+
+	return true
+}
+
+// downloadFile downloads url once, handlers of live mode can download files at the same time.
+// A failed url is forgotten to download it again later.
+func (m *Manager) downloadFile(url string, filepath string) bool {
+	m.filesMutex.Lock()
+
 	_, ok := m.files[url]
 	if ok {
-		return
+		m.filesMutex.Unlock()
+
+		return true
 	}
 
 	m.files[url] = struct{}{}
 
+	m.filesMutex.Unlock()
+
+	downloaded := m.writeFile(url, filepath)
+	if !downloaded {
+		m.filesMutex.Lock()
+		delete(m.files, url)
+		m.filesMutex.Unlock()
+
+		return false
+	}
+
+	m.filesDownloaded.Add(1)
+
+	return true
+}
+
+func (m *Manager) writeFile(url string, filepath string) bool {
 	log.Info().
 		Str("URL", url).
 		Str("filepath", filepath).
@@ -144,7 +463,7 @@ func (m *Manager) downloadFile(url string, filepath string) {
 			Str("filepath", filepath).
 			Msg("discord_bot.exporter.file_downloading_failed")
 
-		return
+		return false
 	}
 
 	//nolint:errcheck
@@ -157,7 +476,7 @@ func (m *Manager) downloadFile(url string, filepath string) {
 			Int("status_code", resp.StatusCode).
 			Msg("discord_bot.exporter.file_downloading_failed")
 
-		return
+		return false
 	}
 
 	//nolint:gosec
@@ -169,7 +488,7 @@ func (m *Manager) downloadFile(url string, filepath string) {
 			Str("reason", "failed to create file on disk").
 			Msg("discord_bot.exporter.file_downloading_failed")
 
-		return
+		return false
 	}
 
 	//nolint:errcheck
@@ -182,10 +501,14 @@ func (m *Manager) downloadFile(url string, filepath string) {
 			Str("filepath", filepath).
 			Str("reason", "failed to copy file on disk").
 			Msg("discord_bot.exporter.file_downloading_failed")
+
+		return false
 	}
 
 	log.Info().
 		Str("URL", url).
 		Str("filepath", filepath).
 		Msg("discord_bot.exporter.file_downloaded")
+
+	return true
 }
@@ -0,0 +1,243 @@
+//nolint:paralleltest
+package exporter_test
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blueprintue/discord-bot/exporter"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRoundTripper struct {
+	idxResponse     int
+	test            *testing.T
+	responsesMocked []*http.Response
+	requestsTest    []requestTest
+}
+
+type requestTest struct {
+	method string
+	host   string
+	uri    string
+}
+
+func (r requestTest) assert(t *testing.T, req *http.Request) {
+	t.Helper()
+
+	require.Equal(t, r.method, req.Method)
+	require.Equal(t, r.host, req.Host)
+	require.Equal(t, r.uri, req.URL.RequestURI())
+}
+
+func (rt *mockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.requestsTest[rt.idxResponse].assert(rt.test, req)
+
+	resp := rt.responsesMocked[rt.idxResponse]
+
+	rt.idxResponse++
+
+	return resp, nil
+}
+
+func createClient(t *testing.T, responses []*http.Response, requests []requestTest) *http.Client {
+	t.Helper()
+
+	return &http.Client{
+		Transport: &mockRoundTripper{
+			idxResponse:     0,
+			test:            t,
+			responsesMocked: responses,
+			requestsTest:    requests,
+		},
+	}
+}
+
+func createJSONResponse(t *testing.T, value any) *http.Response {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	recorder.Header().Add("Content-Type", "application/json")
+	_, err = recorder.Write(data)
+	require.NoError(t, err)
+
+	response := recorder.Result()
+	t.Cleanup(func() { response.Body.Close() })
+
+	return response
+}
+
+func createMessage(id string) *discordgo.Message {
+	return &discordgo.Message{
+		ID:        id,
+		ChannelID: "channel-123",
+		Content:   "message " + id,
+		Timestamp: time.Date(2024, 1, 31, 18, 0, 0, 0, time.UTC),
+		Author:    &discordgo.User{ID: "user-123", Username: "user"},
+	}
+}
+
+//nolint:funlen
+func TestRun_Checkpoints(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", GuildID: "guild-123", Name: "general"}},
+	})
+	require.NoError(t, err)
+
+	outputPath := t.TempDir()
+
+	exporter.ShortenSleeps(t)
+
+	exporterManager := exporter.NewExporterManager(exporter.Configuration{
+		Mode:       "once",
+		OutputPath: outputPath,
+	}, guildName, session)
+	require.NotNil(t, exporterManager)
+
+	t.Cleanup(exporterManager.Stop)
+
+	db, err := sql.Open("sqlite3", path.Join(outputPath, "discord.db"))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	var (
+		oldestMessageID string
+		newestMessageID string
+		isComplete      bool
+	)
+
+	session.Client = createClient(t,
+		[]*http.Response{
+			createJSONResponse(t, discordgo.ThreadsList{}),
+			createJSONResponse(t, []*discordgo.Message{createMessage("1002"), createMessage("1001")}),
+			createJSONResponse(t, discordgo.ThreadsList{}),
+			createJSONResponse(t, discordgo.ThreadsList{}),
+		},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/guilds/guild-123/threads/active"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/public?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/private?limit=100"},
+		},
+	)
+
+	exporterManager.Run()
+
+	err = db.QueryRowContext(t.Context(), `SELECT oldest_message_id, newest_message_id, is_complete FROM checkpoints WHERE channel_id = ?`, "channel-123").
+		Scan(&oldestMessageID, &newestMessageID, &isComplete)
+	require.NoError(t, err)
+	require.Equal(t, "1001", oldestMessageID)
+	require.Equal(t, "1002", newestMessageID)
+	require.True(t, isComplete)
+
+	bufferLogs.Reset()
+
+	session.Client = createClient(t,
+		[]*http.Response{
+			createJSONResponse(t, discordgo.ThreadsList{}),
+			createJSONResponse(t, []*discordgo.Message{createMessage("1003")}),
+			createJSONResponse(t, discordgo.ThreadsList{}),
+			createJSONResponse(t, discordgo.ThreadsList{}),
+		},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/guilds/guild-123/threads/active"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?after=1002&limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/public?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/private?limit=100"},
+		},
+	)
+
+	exporterManager.Run()
+
+	err = db.QueryRowContext(t.Context(), `SELECT oldest_message_id, newest_message_id, is_complete FROM checkpoints WHERE channel_id = ?`, "channel-123").
+		Scan(&oldestMessageID, &newestMessageID, &isComplete)
+	require.NoError(t, err)
+	require.Equal(t, "1001", oldestMessageID)
+	require.Equal(t, "1003", newestMessageID)
+	require.True(t, isComplete)
+
+	var countMessages int
+
+	err = db.QueryRowContext(t.Context(), `SELECT COUNT(*) FROM messages WHERE channel_id = ?`, "channel-123").Scan(&countMessages)
+	require.NoError(t, err)
+	require.Equal(t, 3, countMessages)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.Contains(t, parts, `{"level":"info","channel_id":"channel-123","oldest_message_id":"1001","newest_message_id":"1002","is_complete":true,"message":"discord_bot.exporter.checkpoint_loaded"}`)
+	t.Run("should keep checkpoint before a page with a message not saved", func(t *testing.T) {
+		_, err := db.ExecContext(t.Context(), `CREATE TRIGGER fail_message BEFORE INSERT ON messages WHEN NEW.id = '1004' BEGIN SELECT RAISE(ABORT, 'failed'); END`)
+		require.NoError(t, err)
+
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, discordgo.ThreadsList{}),
+				createJSONResponse(t, []*discordgo.Message{createMessage("1005"), createMessage("1004")}),
+				createJSONResponse(t, discordgo.ThreadsList{}),
+				createJSONResponse(t, discordgo.ThreadsList{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/guilds/guild-123/threads/active"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?after=1003&limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/public?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/private?limit=100"},
+			},
+		)
+
+		exporterManager.Run()
+
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM checkpoints WHERE channel_id = ? AND newest_message_id = ?`, "channel-123", "1003"))
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM messages WHERE id = ?`, "1005"))
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.Contains(t, parts, `{"level":"warn","channel_id":"channel-123","help":"Run the export again to fetch messages not saved","message":"discord_bot.exporter.channel_export_stopped"}`)
+
+		_, err = db.ExecContext(t.Context(), `DROP TRIGGER fail_message`)
+		require.NoError(t, err)
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, discordgo.ThreadsList{}),
+				createJSONResponse(t, []*discordgo.Message{createMessage("1005"), createMessage("1004")}),
+				createJSONResponse(t, discordgo.ThreadsList{}),
+				createJSONResponse(t, discordgo.ThreadsList{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/guilds/guild-123/threads/active"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?after=1003&limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/public?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/threads/archived/private?limit=100"},
+			},
+		)
+
+		exporterManager.Run()
+
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM checkpoints WHERE channel_id = ? AND newest_message_id = ?`, "channel-123", "1005"))
+		require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM messages WHERE id = ?`, "1004"))
+	})
+}
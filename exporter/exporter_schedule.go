@@ -0,0 +1,268 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	countCronFields   int           = 5
+	maxCronSearch     time.Duration = 5 * 366 * 24 * time.Hour
+	minScheduleRepeat time.Duration = time.Minute
+)
+
+var (
+	errCronFieldsCount = errors.New("cron expression must have 5 fields: minute hour day_of_month month day_of_week")
+	errCronValue       = errors.New("invalid value")
+	errCronNeverMatch  = errors.New("cron expression never matches")
+	errIntervalTooLow  = errors.New("interval must be at least 1m")
+)
+
+// schedule is an interval, or a cron expression evaluated in local time.
+type schedule struct {
+	interval time.Duration
+	cron     *cronSchedule
+}
+
+// cronSchedule has allowed values of each field, day of month and day of week match like in cron when both are restricted.
+type cronSchedule struct {
+	minutes       []bool
+	hours         []bool
+	daysOfMonth   []bool
+	months        []bool
+	daysOfWeek    []bool
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+// parseSchedule accepts a duration like "24h" or a cron expression like "0 3 * * *".
+func parseSchedule(value string) (schedule, error) {
+	interval, err := time.ParseDuration(value)
+	if err == nil {
+		if interval < minScheduleRepeat {
+			return schedule{}, errIntervalTooLow
+		}
+
+		return schedule{interval: interval}, nil
+	}
+
+	cron, err := parseCron(value)
+	if err != nil {
+		return schedule{}, err
+	}
+
+	if cron.next(time.Now()).IsZero() {
+		return schedule{}, errCronNeverMatch
+	}
+
+	return schedule{cron: cron}, nil
+}
+
+// next returns the time of the next export after now, zero when cron never matches.
+func (s schedule) next(now time.Time) time.Time {
+	if s.cron == nil {
+		return now.Add(s.interval)
+	}
+
+	return s.cron.next(now)
+}
+
+func parseCron(value string) (*cronSchedule, error) {
+	fields := strings.Fields(value)
+	if len(fields) != countCronFields {
+		return nil, errCronFieldsCount
+	}
+
+	cron := &cronSchedule{
+		anyDayOfMonth: fields[2] == "*",
+		anyDayOfWeek:  fields[4] == "*",
+	}
+
+	bounds := []struct {
+		values   *[]bool
+		min, max int
+	}{
+		{&cron.minutes, 0, 59},
+		{&cron.hours, 0, 23},
+		{&cron.daysOfMonth, 1, 31},
+		{&cron.months, 1, 12},
+		{&cron.daysOfWeek, 0, 7},
+	}
+
+	for idx, bound := range bounds {
+		values, err := parseCronField(fields[idx], bound.min, bound.max)
+		if err != nil {
+			return nil, fmt.Errorf("field %d %q: %w", idx+1, fields[idx], err)
+		}
+
+		*bound.values = values
+	}
+
+	// 7 is Sunday like 0
+	cron.daysOfWeek[0] = cron.daysOfWeek[0] || cron.daysOfWeek[7]
+
+	return cron, nil
+}
+
+// parseCronField accepts "*", values, ranges "a-b" and steps "*/n" or "a-b/n", separated by commas.
+func parseCronField(field string, minValue int, maxValue int) ([]bool, error) {
+	values := make([]bool, maxValue+1)
+
+	for part := range strings.SplitSeq(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+
+		if hasStep {
+			var err error
+
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return nil, errCronValue
+			}
+		}
+
+		start, end := minValue, maxValue
+
+		if rangePart != "*" {
+			startPart, endPart, hasRange := strings.Cut(rangePart, "-")
+
+			var err error
+
+			start, err = strconv.Atoi(startPart)
+			if err != nil {
+				return nil, errCronValue
+			}
+
+			end = start
+
+			if hasRange {
+				end, err = strconv.Atoi(endPart)
+				if err != nil {
+					return nil, errCronValue
+				}
+			} else if hasStep {
+				end = maxValue
+			}
+		}
+
+		if start < minValue || end > maxValue || start > end {
+			return nil, errCronValue
+		}
+
+		for value := start; value <= end; value += step {
+			values[value] = true
+		}
+	}
+
+	return values, nil
+}
+
+func (c *cronSchedule) matchDay(date time.Time) bool {
+	matchDayOfMonth := c.daysOfMonth[date.Day()]
+	matchDayOfWeek := c.daysOfWeek[int(date.Weekday())]
+
+	switch {
+	case c.anyDayOfMonth && c.anyDayOfWeek:
+		return true
+	case c.anyDayOfMonth:
+		return matchDayOfWeek
+	case c.anyDayOfWeek:
+		return matchDayOfMonth
+	default:
+		return matchDayOfMonth || matchDayOfWeek
+	}
+}
+
+// next skips months, days and hours which do not match before checking minutes.
+func (c *cronSchedule) next(now time.Time) time.Time {
+	date := now.Truncate(time.Minute).Add(time.Minute)
+	limit := now.Add(maxCronSearch)
+
+	for date.Before(limit) {
+		switch {
+		case !c.months[int(date.Month())]:
+			date = time.Date(date.Year(), date.Month()+1, 1, 0, 0, 0, 0, date.Location())
+		case !c.matchDay(date):
+			date = time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, date.Location())
+		case !c.hours[date.Hour()]:
+			date = time.Date(date.Year(), date.Month(), date.Day(), date.Hour()+1, 0, 0, 0, date.Location())
+		case !c.minutes[date.Minute()]:
+			date = date.Add(time.Minute)
+		default:
+			return date
+		}
+	}
+
+	return time.Time{}
+}
+
+// startSchedule runs exports in background, an export is skipped when the previous one is still running.
+func (m *Manager) startSchedule() {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m.cancelSchedule = cancel
+	m.scheduleStopped = make(chan struct{})
+
+	log.Info().
+		Str("schedule", m.scheduleValue).
+		Msg("discord_bot.exporter.starting_schedule")
+
+	next := m.nextExport()
+
+	go func() {
+		defer close(m.scheduleStopped)
+
+		for {
+			timer := time.NewTimer(time.Until(next))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				return
+			case <-timer.C:
+				m.exports.Go(func() {
+					m.export(ctx)
+				})
+			}
+
+			next = m.nextExport()
+		}
+	}()
+}
+
+func (m *Manager) nextExport() time.Time {
+	next := m.schedule.next(time.Now())
+
+	log.Info().
+		Time("next_export_at", next).
+		Msg("discord_bot.exporter.export_scheduled")
+
+	return next
+}
+
+// stopSchedule cancels the export in progress, it stops after the page of messages being saved.
+func (m *Manager) stopSchedule() {
+	if m.cancelSchedule == nil {
+		return
+	}
+
+	log.Info().
+		Msg("discord_bot.exporter.stopping_schedule")
+
+	m.cancelSchedule()
+	<-m.scheduleStopped
+	m.exports.Wait()
+
+	m.cancelSchedule = nil
+
+	log.Info().
+		Msg("discord_bot.exporter.schedule_stopped")
+}
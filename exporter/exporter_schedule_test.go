@@ -0,0 +1,128 @@
+//nolint:paralleltest
+package exporter_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/exporter"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewExporterManager_ErrorSchedule(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	helpSchedule := `"help":"Accepted values are intervals like '24h' or cron expressions like '0 3 * * *'"`
+
+	tests := []struct {
+		name     string
+		mode     string
+		schedule string
+		log      string
+	}{
+		{
+			name:     "should return nil because schedule is empty",
+			mode:     "scheduled",
+			schedule: "",
+			log:      `{"level":"error","error":"cron expression must have 5 fields: minute hour day_of_month month day_of_week","schedule":"",` + helpSchedule + `,"message":"discord_bot.exporter.configuration_invalid_schedule"}`,
+		},
+		{
+			name:     "should return nil because interval is too low",
+			mode:     "scheduled",
+			schedule: "30s",
+			log:      `{"level":"error","error":"interval must be at least 1m","schedule":"30s",` + helpSchedule + `,"message":"discord_bot.exporter.configuration_invalid_schedule"}`,
+		},
+		{
+			name:     "should return nil because hour is out of range",
+			mode:     "scheduled",
+			schedule: "0 25 * * *",
+			log:      `{"level":"error","error":"field 2 \"25\": invalid value","schedule":"0 25 * * *",` + helpSchedule + `,"message":"discord_bot.exporter.configuration_invalid_schedule"}`,
+		},
+		{
+			name:     "should return nil because step is invalid",
+			mode:     "scheduled",
+			schedule: "*/0 * * * *",
+			log:      `{"level":"error","error":"field 1 \"*/0\": invalid value","schedule":"*/0 * * * *",` + helpSchedule + `,"message":"discord_bot.exporter.configuration_invalid_schedule"}`,
+		},
+		{
+			name:     "should return nil because cron never matches",
+			mode:     "scheduled",
+			schedule: "0 3 31 2 *",
+			log:      `{"level":"error","error":"cron expression never matches","schedule":"0 3 31 2 *",` + helpSchedule + `,"message":"discord_bot.exporter.configuration_invalid_schedule"}`,
+		},
+		{
+			name:     "should return nil because schedule is not used",
+			mode:     "once",
+			schedule: "24h",
+			log:      `{"level":"error","help":"schedule is only used with mode 'scheduled'","message":"discord_bot.exporter.configuration_unused_schedule"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bufferLogs.Reset()
+
+			exporterManager := exporter.NewExporterManager(exporter.Configuration{
+				Mode:     tt.mode,
+				Schedule: tt.schedule,
+			}, guildName, session)
+			require.Nil(t, exporterManager)
+
+			parts := strings.Split(bufferLogs.String(), "\n")
+			require.JSONEq(t, tt.log, parts[2])
+			require.JSONEq(t, `{"level":"error","message":"discord_bot.exporter.configuration_validation_failed"}`, parts[3])
+			require.Empty(t, parts[4])
+		})
+	}
+}
+
+func TestRun_Scheduled(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	exporterManager := exporter.NewExporterManager(exporter.Configuration{
+		Mode:       "scheduled",
+		Schedule:   "30 2 * * 1-5",
+		OutputPath: t.TempDir(),
+	}, guildName, session)
+	require.NotNil(t, exporterManager)
+
+	bufferLogs.Reset()
+
+	exporterManager.Run()
+	exporterManager.Stop()
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.starting"}`, parts[0])
+	require.JSONEq(t, `{"level":"info","schedule":"30 2 * * 1-5","message":"discord_bot.exporter.starting_schedule"}`, parts[1])
+
+	var logScheduled struct {
+		Message      string `json:"message"`
+		NextExportAt string `json:"next_export_at"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(parts[2]), &logScheduled))
+	require.Equal(t, "discord_bot.exporter.export_scheduled", logScheduled.Message)
+	require.Contains(t, logScheduled.NextExportAt, "T02:30:00")
+
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.stopping"}`, parts[3])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.stopping_schedule"}`, parts[4])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.schedule_stopped"}`, parts[5])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.database_closed"}`, parts[6])
+	require.Empty(t, parts[7])
+}
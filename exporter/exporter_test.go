@@ -60,8 +60,12 @@ func SkipTestNewExporterManager(t *testing.T) {
 	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.channels_table_created"}`, parts[26])
 	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.creating_messages_table"}`, parts[27])
 	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.messages_table_created"}`, parts[28])
-	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.database_initialized"}`, parts[29])
-	require.Empty(t, parts[30])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.creating_checkpoints_table"}`, parts[29])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.checkpoints_table_created"}`, parts[30])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.creating_attachments_table"}`, parts[31])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.attachments_table_created"}`, parts[32])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.database_initialized"}`, parts[33])
+	require.Empty(t, parts[34])
 }
 
 func TestNewExporterManager_ErrorInvalidMode(t *testing.T) {
@@ -79,7 +83,7 @@ func TestNewExporterManager_ErrorInvalidMode(t *testing.T) {
 
 	parts := strings.Split(bufferLogs.String(), "\n")
 	require.JSONEq(t, `{"level":"info","message":"discord_bot.exporter.validating_configuration"}`, parts[0])
-	require.JSONEq(t, `{"level":"error","help":"Accepted values are 'once'","message":"discord_bot.exporter.configuration_invalid_mode"}`, parts[1])
+	require.JSONEq(t, `{"level":"error","help":"Accepted values are 'once', 'live', 'scheduled'","message":"discord_bot.exporter.configuration_invalid_mode"}`, parts[1])
 	require.JSONEq(t, `{"level":"error","message":"discord_bot.exporter.configuration_validation_failed"}`, parts[2])
 	require.Empty(t, parts[3])
 }
@@ -0,0 +1,146 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	limitArchivedThreads          = 100
+	limitLoopFetchArchivedThreads = 100
+)
+
+type fetchArchivedThreadsFunc func(channelID string, before *time.Time, limit int, options ...discordgo.RequestOption) (*discordgo.ThreadsList, error)
+
+// hasMessages is false for channels which only contain threads or nothing.
+func hasMessages(channel *discordgo.Channel) bool {
+	switch channel.Type {
+	case discordgo.ChannelTypeGuildCategory, discordgo.ChannelTypeGuildForum, discordgo.ChannelTypeGuildMedia:
+		return false
+	default:
+		return true
+	}
+}
+
+func hasThreads(channel *discordgo.Channel) bool {
+	switch channel.Type {
+	case discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews, discordgo.ChannelTypeGuildForum, discordgo.ChannelTypeGuildMedia:
+		return true
+	default:
+		return false
+	}
+}
+
+// fetchActiveThreads returns active threads of guild by parent channel ID.
+func (m *Manager) fetchActiveThreads(guildID string) map[string][]*discordgo.Channel {
+	threadsByParentID := map[string][]*discordgo.Channel{}
+
+	threadsList, err := m.discordSession.GuildThreadsActive(guildID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("guild_id", guildID).
+			Msg("discord_bot.exporter.active_threads_fetching_failed")
+
+		return threadsByParentID
+	}
+
+	for _, thread := range threadsList.Threads {
+		threadsByParentID[thread.ParentID] = append(threadsByParentID[thread.ParentID], thread)
+	}
+
+	return threadsByParentID
+}
+
+// fetchArchivedThreads pages through archived threads of channel, from the most recently archived.
+func (m *Manager) fetchArchivedThreads(channelID string, visibility string, fetch fetchArchivedThreadsFunc) []*discordgo.Channel {
+	var (
+		threads []*discordgo.Channel
+		before  *time.Time
+	)
+
+	for range limitLoopFetchArchivedThreads {
+		threadsList, err := fetch(channelID, before, limitArchivedThreads)
+		if err != nil {
+			log.Warn().Err(err).
+				Str("channel_id", channelID).
+				Str("visibility", visibility).
+				Msg("discord_bot.exporter.archived_threads_fetching_failed")
+
+			return threads
+		}
+
+		threads = append(threads, threadsList.Threads...)
+
+		if !threadsList.HasMore || len(threadsList.Threads) == 0 {
+			return threads
+		}
+
+		lastThread := threadsList.Threads[len(threadsList.Threads)-1]
+		if lastThread.ThreadMetadata == nil {
+			return threads
+		}
+
+		archiveTimestamp := lastThread.ThreadMetadata.ArchiveTimestamp
+		before = &archiveTimestamp
+	}
+
+	return threads
+}
+
+// exportThreadsOfChannel saves active and archived threads of channel as channels with their parent, then their messages.
+// Private archived threads need the permission Manage Threads, they are skipped without it.
+func (m *Manager) exportThreadsOfChannel(ctx context.Context, guildID string, channel *discordgo.Channel, activeThreads []*discordgo.Channel) {
+	if !hasThreads(channel) {
+		return
+	}
+
+	threads := append([]*discordgo.Channel{}, activeThreads...)
+	threads = append(threads, m.fetchArchivedThreads(channel.ID, "public", m.discordSession.ThreadsArchived)...)
+
+	if channel.Type == discordgo.ChannelTypeGuildText {
+		threads = append(threads, m.fetchArchivedThreads(channel.ID, "private", m.discordSession.ThreadsPrivateArchived)...)
+	}
+
+	log.Info().
+		Str("channel_id", channel.ID).
+		Int("count_threads", len(threads)).
+		Msg("discord_bot.exporter.threads_fetched")
+
+	for _, thread := range threads {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if thread.GuildID == "" {
+			thread.GuildID = guildID
+		}
+
+		saved := m.addOrUpdateChannel(ctx, translateChannel(thread))
+		if !saved {
+			continue
+		}
+
+		m.fetchMessagesFromChannel(ctx, guildID, thread.ID)
+	}
+}
+
+// isThreadOrChannelExported applies rules of channels to the parent channel of threads.
+func (m *Manager) isThreadOrChannelExported(channel *discordgo.Channel) bool {
+	if !channel.IsThread() {
+		return m.isChannelExported(channel)
+	}
+
+	parent, err := m.discordSession.State.Channel(channel.ParentID)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("channel_id", channel.ParentID).
+			Msg("discord_bot.exporter.channel_not_found")
+
+		return false
+	}
+
+	return m.isChannelExported(parent)
+}
@@ -0,0 +1,75 @@
+//nolint:paralleltest
+package exporter_test
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"path"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/exporter"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_ForumPosts(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "forum-123", GuildID: "guild-123", Name: "help", Type: discordgo.ChannelTypeGuildForum}},
+	})
+	require.NoError(t, err)
+
+	outputPath := t.TempDir()
+
+	exporter.ShortenSleeps(t)
+
+	exporterManager := exporter.NewExporterManager(exporter.Configuration{
+		Mode:       "once",
+		OutputPath: outputPath,
+	}, guildName, session)
+	require.NotNil(t, exporterManager)
+
+	t.Cleanup(exporterManager.Stop)
+
+	db, err := sql.Open("sqlite3", path.Join(outputPath, "discord.db"))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	message := createMessage("1001")
+	message.ChannelID = "thread-123"
+
+	session.Client = createClient(t,
+		[]*http.Response{
+			createJSONResponse(t, discordgo.ThreadsList{Threads: []*discordgo.Channel{
+				{ID: "thread-123", GuildID: "guild-123", ParentID: "forum-123", Name: "my post", Type: discordgo.ChannelTypeGuildPublicThread},
+				{ID: "thread-456", GuildID: "guild-123", ParentID: "channel-456", Name: "not exported", Type: discordgo.ChannelTypeGuildPublicThread},
+			}}),
+			createJSONResponse(t, discordgo.ThreadsList{}),
+			createJSONResponse(t, []*discordgo.Message{message}),
+		},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/guilds/guild-123/threads/active"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/forum-123/threads/archived/public?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/thread-123/messages?limit=100"},
+		},
+	)
+
+	exporterManager.Run()
+
+	require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM channels WHERE id = ? AND parent_id = ? AND type = ?`, "thread-123", "forum-123", "guild_public_thread"))
+	require.Equal(t, 0, countRows(t, db, `SELECT COUNT(*) FROM channels WHERE id = ?`, "thread-456"))
+	require.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM messages WHERE channel_id = ?`, "thread-123"))
+}
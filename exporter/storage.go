@@ -49,6 +49,38 @@ func initializeDatabase(outputPath string, databaseFilename string) *sql.DB {
 		return nil
 	}
 
+	created = addColumn(ctx, db, "channels", "deleted_at", "VARCHAR (255) NULL")
+	if !created {
+		//nolint:errcheck
+		defer db.Close()
+
+		return nil
+	}
+
+	created = addColumn(ctx, db, "messages", "deleted_at", "VARCHAR (255) NULL")
+	if !created {
+		//nolint:errcheck
+		defer db.Close()
+
+		return nil
+	}
+
+	created = createCheckpointsTable(ctx, db)
+	if !created {
+		//nolint:errcheck
+		defer db.Close()
+
+		return nil
+	}
+
+	created = createAttachmentsTable(ctx, db)
+	if !created {
+		//nolint:errcheck
+		defer db.Close()
+
+		return nil
+	}
+
 	return db
 }
 
@@ -104,3 +136,71 @@ func openFile(ctx context.Context, outputPath string, databaseFilename string) *
 
 	return db
 }
+
+// addColumn adds column to table of databases created before the column existed.
+func addColumn(ctx context.Context, db *sql.DB, table string, column string, definition string) bool {
+	var count int
+
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	if err != nil {
+		log.Error().Err(err).
+			Str("table", table).
+			Str("column", column).
+			Str("step", "query_row_context").
+			Msg("discord_bot.exporter.column_adding_failed")
+
+		return false
+	}
+
+	if count > 0 {
+		return true
+	}
+
+	log.Info().
+		Str("table", table).
+		Str("column", column).
+		Msg("discord_bot.exporter.adding_column")
+
+	// table, column and definition are never user inputs, they cannot be bound parameters of ALTER TABLE.
+	_, err = db.ExecContext(ctx, `ALTER TABLE "`+table+`" ADD COLUMN `+column+` `+definition)
+	if err != nil {
+		log.Error().Err(err).
+			Str("table", table).
+			Str("column", column).
+			Str("step", "exec_context").
+			Msg("discord_bot.exporter.column_adding_failed")
+
+		return false
+	}
+
+	log.Info().
+		Str("table", table).
+		Str("column", column).
+		Msg("discord_bot.exporter.column_added")
+
+	return true
+}
+
+// exportCounts is the number of rows of tables and of files downloaded, used to log what an export added.
+type exportCounts struct {
+	guilds   int
+	channels int
+	messages int
+	files    int64
+}
+
+func (e *Manager) countExported(ctx context.Context) exportCounts {
+	counts := exportCounts{files: e.filesDownloaded.Load()}
+
+	err := e.db.QueryRowContext(ctx, `SELECT
+		(SELECT COUNT(*) FROM guilds),
+		(SELECT COUNT(*) FROM channels),
+		(SELECT COUNT(*) FROM messages)`).
+		Scan(&counts.guilds, &counts.channels, &counts.messages)
+	if err != nil {
+		log.Error().Err(err).
+			Msg("discord_bot.exporter.counting_failed")
+	}
+
+	return counts
+}
@@ -0,0 +1,196 @@
+package exporter
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/bwmarrin/discordgo"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	attachmentDownloaded string = "downloaded"
+	attachmentFailed     string = "failed"
+
+	maxAttachmentAttempts = 5
+)
+
+// attachmentStorage is an attachment of a message, LocalPath is relative to the output path.
+// Attempts counts failed downloads, ChannelID is only loaded with failed attachments to refresh their URL.
+type attachmentStorage struct {
+	ID          string
+	MessageID   string
+	Filename    string
+	ContentType string
+	Size        int
+	Width       int
+	Height      int
+	URL         string
+	LocalPath   string
+	Status      string
+	Attempts    int
+	ChannelID   string
+}
+
+func createAttachmentsTable(ctx context.Context, db *sql.DB) bool {
+	log.Info().
+		Msg("discord_bot.exporter.creating_attachments_table")
+
+	statement, err := db.PrepareContext(ctx, `
+	CREATE TABLE IF NOT EXISTS "attachments" (
+		id           VARCHAR (31) PRIMARY KEY,
+		message_id   VARCHAR (31) NOT NULL,
+		filename     VARCHAR (255) NOT NULL,
+		content_type VARCHAR (255) NULL,
+		size         INTEGER NOT NULL,
+		width        INTEGER NULL,
+		height       INTEGER NULL,
+		url          TEXT NOT NULL,
+		local_path   TEXT NOT NULL,
+		status       VARCHAR (31) NOT NULL,
+		attempts     INTEGER NOT NULL DEFAULT 0
+	);`)
+	if err != nil {
+		log.Error().Err(err).
+			Str("step", "prepare_context").
+			Msg("discord_bot.exporter.attachments_table_creating_failed")
+
+		return false
+	}
+
+	//nolint:errcheck
+	defer statement.Close()
+
+	_, err = statement.ExecContext(ctx)
+	if err != nil {
+		log.Error().Err(err).
+			Str("step", "exec_context").
+			Msg("discord_bot.exporter.attachments_table_creating_failed")
+
+		return false
+	}
+
+	log.Info().
+		Msg("discord_bot.exporter.attachments_table_created")
+
+	return true
+}
+
+func translateAttachment(attachment *discordgo.MessageAttachment, messageID string, localPath string, status string) attachmentStorage {
+	return attachmentStorage{
+		ID:          attachment.ID,
+		MessageID:   messageID,
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Width:       attachment.Width,
+		Height:      attachment.Height,
+		URL:         attachment.URL,
+		LocalPath:   localPath,
+		Status:      status,
+	}
+}
+
+func (e *Manager) addOrUpdateAttachment(ctx context.Context, attachment attachmentStorage) bool {
+	log.Info().
+		Str("id", attachment.ID).
+		Msg("discord_bot.exporter.saving_attachment")
+
+	statement, err := e.db.PrepareContext(ctx, `REPLACE INTO attachments (id, message_id, filename, content_type, size, width, height, url, local_path, status, attempts)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		log.Error().Err(err).
+			Str("id", attachment.ID).
+			Str("step", "prepare_context").
+			Msg("discord_bot.exporter.attachment_saving_failed")
+
+		return false
+	}
+
+	//nolint:errcheck
+	defer statement.Close()
+
+	_, err = statement.ExecContext(ctx,
+		attachment.ID,
+		attachment.MessageID,
+		attachment.Filename,
+		attachment.ContentType,
+		attachment.Size,
+		attachment.Width,
+		attachment.Height,
+		attachment.URL,
+		attachment.LocalPath,
+		attachment.Status,
+		attachment.Attempts,
+	)
+	if err != nil {
+		log.Error().Err(err).
+			Str("id", attachment.ID).
+			Str("step", "exec_context").
+			Msg("discord_bot.exporter.attachment_saving_failed")
+
+		return false
+	}
+
+	log.Info().
+		Str("id", attachment.ID).
+		Msg("discord_bot.exporter.attachment_saved")
+
+	return true
+}
+
+// getFailedAttachments returns attachments which were not downloaded and have attempts left, to download them again.
+func (e *Manager) getFailedAttachments(ctx context.Context) []attachmentStorage {
+	rows, err := e.db.QueryContext(ctx, `SELECT a.id, a.message_id, a.filename, a.content_type, a.size, a.width, a.height, a.url, a.local_path, a.status, a.attempts, m.channel_id
+	FROM attachments a INNER JOIN messages m ON m.id = a.message_id
+	WHERE a.status = ? AND a.attempts < ?`, attachmentFailed, maxAttachmentAttempts)
+	if err != nil {
+		log.Error().Err(err).
+			Msg("discord_bot.exporter.failed_attachments_loading_failed")
+
+		return nil
+	}
+
+	//nolint:errcheck
+	defer rows.Close()
+
+	var attachments []attachmentStorage
+
+	for rows.Next() {
+		var attachment attachmentStorage
+
+		err = rows.Scan(
+			&attachment.ID,
+			&attachment.MessageID,
+			&attachment.Filename,
+			&attachment.ContentType,
+			&attachment.Size,
+			&attachment.Width,
+			&attachment.Height,
+			&attachment.URL,
+			&attachment.LocalPath,
+			&attachment.Status,
+			&attachment.Attempts,
+			&attachment.ChannelID,
+		)
+		if err != nil {
+			log.Error().Err(err).
+				Msg("discord_bot.exporter.failed_attachments_loading_failed")
+
+			return nil
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		log.Error().Err(err).
+			Msg("discord_bot.exporter.failed_attachments_loading_failed")
+
+		return nil
+	}
+
+	return attachments
+}
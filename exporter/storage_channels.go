@@ -26,14 +26,15 @@ func createChannelsTable(ctx context.Context, db *sql.DB) bool {
 
 	statement, err := db.PrepareContext(ctx, `
 	CREATE TABLE IF NOT EXISTS "channels" (
-		id        VARCHAR (31) PRIMARY KEY,
-		guild_id  VARCHAR (255) NOT NULL,
-		name      VARCHAR (255) NOT NULL,
-		topic     TEXT NULL,
-		type      VARCHAR (255) NOT NULL,
-		position  INTEGER NOT NULL,
-		parent_id VARCHAR (255) NULL,
-		owner_id  VARCHAR (255) NOT NULL
+		id         VARCHAR (31) PRIMARY KEY,
+		guild_id   VARCHAR (255) NOT NULL,
+		name       VARCHAR (255) NOT NULL,
+		topic      TEXT NULL,
+		type       VARCHAR (255) NOT NULL,
+		position   INTEGER NOT NULL,
+		parent_id  VARCHAR (255) NULL,
+		owner_id   VARCHAR (255) NOT NULL,
+		deleted_at VARCHAR (255) NULL
 	);`)
 	if err != nil {
 		log.Error().Err(err).
@@ -154,3 +155,39 @@ func (e *Manager) addOrUpdateChannel(ctx context.Context, channel channelStorage
 
 	return true
 }
+
+// markChannelDeleted keeps the channel in the database and records when it was deleted.
+func (e *Manager) markChannelDeleted(ctx context.Context, channelID string, deletedAt string) bool {
+	log.Info().
+		Str("id", channelID).
+		Msg("discord_bot.exporter.marking_channel_deleted")
+
+	statement, err := e.db.PrepareContext(ctx, `UPDATE channels SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`)
+	if err != nil {
+		log.Error().Err(err).
+			Str("id", channelID).
+			Str("step", "prepare_context").
+			Msg("discord_bot.exporter.channel_marking_deleted_failed")
+
+		return false
+	}
+
+	//nolint:errcheck
+	defer statement.Close()
+
+	_, err = statement.ExecContext(ctx, deletedAt, channelID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("id", channelID).
+			Str("step", "exec_context").
+			Msg("discord_bot.exporter.channel_marking_deleted_failed")
+
+		return false
+	}
+
+	log.Info().
+		Str("id", channelID).
+		Msg("discord_bot.exporter.channel_marked_deleted")
+
+	return true
+}
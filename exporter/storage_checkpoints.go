@@ -0,0 +1,125 @@
+package exporter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rs/zerolog/log"
+)
+
+// checkpointStorage is the range of messages already exported in a channel.
+// IsComplete is set when the export reached the first message of the channel.
+type checkpointStorage struct {
+	ChannelID       string
+	OldestMessageID string
+	NewestMessageID string
+	IsComplete      bool
+}
+
+func createCheckpointsTable(ctx context.Context, db *sql.DB) bool {
+	log.Info().
+		Msg("discord_bot.exporter.creating_checkpoints_table")
+
+	statement, err := db.PrepareContext(ctx, `
+	CREATE TABLE IF NOT EXISTS "checkpoints" (
+		channel_id        VARCHAR (31) PRIMARY KEY,
+		oldest_message_id VARCHAR (31) NOT NULL,
+		newest_message_id VARCHAR (31) NOT NULL,
+		is_complete       INTEGER NOT NULL
+	);`)
+	if err != nil {
+		log.Error().Err(err).
+			Str("step", "prepare_context").
+			Msg("discord_bot.exporter.checkpoints_table_creating_failed")
+
+		return false
+	}
+
+	//nolint:errcheck
+	defer statement.Close()
+
+	_, err = statement.ExecContext(ctx)
+	if err != nil {
+		log.Error().Err(err).
+			Str("step", "exec_context").
+			Msg("discord_bot.exporter.checkpoints_table_creating_failed")
+
+		return false
+	}
+
+	log.Info().
+		Msg("discord_bot.exporter.checkpoints_table_created")
+
+	return true
+}
+
+// getCheckpoint returns an empty checkpoint when the channel was never exported.
+func (e *Manager) getCheckpoint(ctx context.Context, channelID string) (checkpointStorage, bool) {
+	log.Info().
+		Str("channel_id", channelID).
+		Msg("discord_bot.exporter.loading_checkpoint")
+
+	checkpoint := checkpointStorage{ChannelID: channelID}
+
+	err := e.db.QueryRowContext(ctx, `SELECT oldest_message_id, newest_message_id, is_complete FROM checkpoints WHERE channel_id = ?`, channelID).
+		Scan(&checkpoint.OldestMessageID, &checkpoint.NewestMessageID, &checkpoint.IsComplete)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error().Err(err).
+			Str("channel_id", channelID).
+			Msg("discord_bot.exporter.checkpoint_loading_failed")
+
+		return checkpoint, false
+	}
+
+	log.Info().
+		Str("channel_id", channelID).
+		Str("oldest_message_id", checkpoint.OldestMessageID).
+		Str("newest_message_id", checkpoint.NewestMessageID).
+		Bool("is_complete", checkpoint.IsComplete).
+		Msg("discord_bot.exporter.checkpoint_loaded")
+
+	return checkpoint, true
+}
+
+func (e *Manager) addOrUpdateCheckpoint(ctx context.Context, checkpoint checkpointStorage) bool {
+	log.Info().
+		Str("channel_id", checkpoint.ChannelID).
+		Msg("discord_bot.exporter.saving_checkpoint")
+
+	statement, err := e.db.PrepareContext(ctx, `REPLACE INTO checkpoints (channel_id, oldest_message_id, newest_message_id, is_complete)
+	VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		log.Error().Err(err).
+			Str("channel_id", checkpoint.ChannelID).
+			Str("step", "prepare_context").
+			Msg("discord_bot.exporter.checkpoint_saving_failed")
+
+		return false
+	}
+
+	//nolint:errcheck
+	defer statement.Close()
+
+	_, err = statement.ExecContext(ctx,
+		checkpoint.ChannelID,
+		checkpoint.OldestMessageID,
+		checkpoint.NewestMessageID,
+		checkpoint.IsComplete,
+	)
+	if err != nil {
+		log.Error().Err(err).
+			Str("channel_id", checkpoint.ChannelID).
+			Str("step", "exec_context").
+			Msg("discord_bot.exporter.checkpoint_saving_failed")
+
+		return false
+	}
+
+	log.Info().
+		Str("channel_id", checkpoint.ChannelID).
+		Msg("discord_bot.exporter.checkpoint_saved")
+
+	return true
+}
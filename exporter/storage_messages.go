@@ -34,7 +34,8 @@ func createMessagesTable(ctx context.Context, db *sql.DB) bool {
 		author_id  VARCHAR (255) NOT NULL,
 		content    TEXT NOT NULL,
 		sent_at    VARCHAR (255) NOT NULL,
-		is_embed   INTEGER
+		is_embed   INTEGER,
+		deleted_at VARCHAR (255) NULL
 	);`)
 	if err != nil {
 		log.Error().Err(err).
@@ -156,3 +157,75 @@ func (e *Manager) addOrUpdateMessage(ctx context.Context, message messageStorage
 
 	return true
 }
+
+// markMessageDeleted keeps the message in the database and records when it was deleted.
+func (e *Manager) markMessageDeleted(ctx context.Context, messageID string, deletedAt string) bool {
+	log.Info().
+		Str("id", messageID).
+		Msg("discord_bot.exporter.marking_message_deleted")
+
+	statement, err := e.db.PrepareContext(ctx, `UPDATE messages SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`)
+	if err != nil {
+		log.Error().Err(err).
+			Str("id", messageID).
+			Str("step", "prepare_context").
+			Msg("discord_bot.exporter.message_marking_deleted_failed")
+
+		return false
+	}
+
+	//nolint:errcheck
+	defer statement.Close()
+
+	_, err = statement.ExecContext(ctx, deletedAt, messageID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("id", messageID).
+			Str("step", "exec_context").
+			Msg("discord_bot.exporter.message_marking_deleted_failed")
+
+		return false
+	}
+
+	log.Info().
+		Str("id", messageID).
+		Msg("discord_bot.exporter.message_marked_deleted")
+
+	return true
+}
+
+// markMessagesOfChannelDeleted keeps messages of the channel in the database and records when they were deleted.
+func (e *Manager) markMessagesOfChannelDeleted(ctx context.Context, channelID string, deletedAt string) bool {
+	log.Info().
+		Str("channel_id", channelID).
+		Msg("discord_bot.exporter.marking_channel_messages_deleted")
+
+	statement, err := e.db.PrepareContext(ctx, `UPDATE messages SET deleted_at = ? WHERE channel_id = ? AND deleted_at IS NULL`)
+	if err != nil {
+		log.Error().Err(err).
+			Str("channel_id", channelID).
+			Str("step", "prepare_context").
+			Msg("discord_bot.exporter.channel_messages_marking_deleted_failed")
+
+		return false
+	}
+
+	//nolint:errcheck
+	defer statement.Close()
+
+	_, err = statement.ExecContext(ctx, deletedAt, channelID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("channel_id", channelID).
+			Str("step", "exec_context").
+			Msg("discord_bot.exporter.channel_messages_marking_deleted_failed")
+
+		return false
+	}
+
+	log.Info().
+		Str("channel_id", channelID).
+		Msg("discord_bot.exporter.channel_messages_marked_deleted")
+
+	return true
+}
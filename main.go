@@ -104,14 +104,14 @@ pending_discord_session_open_completely:
 		}
 	}
 
-	startModuleExporter(config.Modules.ExporterConfiguration, config.Discord.Name, discordSession)
+	exporterManager := startModuleExporter(config.Modules.ExporterConfiguration, config.Discord.Name, discordSession)
 
 	log.Info().
 		Msg("discord_bot.main.discord_session_opened")
 
 	healthchecksManager := startModuleHealthchecks(config.Modules.HealthcheckConfiguration)
 
-	startModuleWelcome(config.Modules.WelcomeConfiguration, config.Discord.Name, discordSession)
+	welcomeManager := startModuleWelcome(config.Modules.WelcomeConfiguration, config.Discord.Name, discordSession)
 
 	log.Info().
 		Str("help", "Press CTRL+C to stop").
@@ -121,6 +121,14 @@ pending_discord_session_open_completely:
 	signal.Notify(sighupChan, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	sig := <-sighupChan
 
+	if welcomeManager != nil {
+		welcomeManager.Stop()
+	}
+
+	if exporterManager != nil {
+		exporterManager.Stop()
+	}
+
 	closeSessionDiscord(discordSession)
 
 	if healthchecksManager != nil {
@@ -155,12 +163,12 @@ func closeSessionDiscord(discordSession *discordgo.Session) {
 	}
 }
 
-func startModuleExporter(configuration *exporter.Configuration, guildName string, discordSession *discordgo.Session) {
+func startModuleExporter(configuration *exporter.Configuration, guildName string, discordSession *discordgo.Session) *exporter.Manager {
 	if configuration == nil {
 		log.Info().
 			Msg("discord_bot.main.exporter.skipped")
 
-		return
+		return nil
 	}
 
 	log.Info().
@@ -171,13 +179,15 @@ func startModuleExporter(configuration *exporter.Configuration, guildName string
 		log.Error().
 			Msg("discord_bot.main.exporter.creating_failed")
 
-		return
+		return nil
 	}
 
 	log.Info().
 		Msg("discord_bot.main.exporter.created")
 
 	exporterManager.Run()
+
+	return exporterManager
 }
 
 func startModuleHealthchecks(configuration *healthchecks.Configuration) *healthchecks.Manager {
@@ -219,12 +229,12 @@ func startModuleHealthchecks(configuration *healthchecks.Configuration) *healthc
 	return healthchecksManager
 }
 
-func startModuleWelcome(configuration *welcome.Configuration, guildName string, discordSession *discordgo.Session) {
+func startModuleWelcome(configuration *welcome.Configuration, guildName string, discordSession *discordgo.Session) *welcome.Manager {
 	if configuration == nil {
 		log.Info().
 			Msg("discord_bot.main.welcome.skipped")
 
-		return
+		return nil
 	}
 
 	log.Info().
@@ -235,7 +245,7 @@ func startModuleWelcome(configuration *welcome.Configuration, guildName string,
 		log.Error().
 			Msg("discord_bot.main.welcome.creation_failed")
 
-		return
+		return nil
 	}
 
 	log.Info().
@@ -249,9 +259,11 @@ func startModuleWelcome(configuration *welcome.Configuration, guildName string,
 		log.Error().Err(err).
 			Msg("discord_bot.main.welcome.start_failed")
 
-		return
+		return nil
 	}
 
 	log.Info().
 		Msg("discord_bot.main.welcome.started")
+
+	return welcomeManager
 }
@@ -3,47 +3,192 @@ package welcome
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/rs/zerolog/log"
 )
 
 const (
-	limitChannelMessages int = 100
-	stepOneConfiguration int = 1
-	stepTwoConfiguration int = 2
+	limitChannelMessages     int = 100
+	defaultScanLimitMessages int = 1000
+	defaultMaxRolesRemoved   int = 50
+	limitComponents          int = 25
+	stepOneConfiguration     int = 1
+	stepTwoConfiguration     int = 2
+)
+
+const (
+	messageTypeReactions  string = "reactions"
+	messageTypeButtons    string = "buttons"
+	messageTypeSelectMenu string = "select_menu"
 )
 
 // Configuration is a struct.
+// Channel is the channel of messages which do not define their own channel, directly or with their group.
+// Channel, roles and emojis are names or IDs, explicit ChannelID, RoleID and EmojiID take precedence over them.
+// DeleteUnknownMessages allows to delete messages of the bot in channel which do not match any message.
+// StateFilename is the file where IDs of messages are saved to find them without scanning channel.
+// ScanLimitMessages is the maximum number of messages scanned in channel to find messages.
+// ReconcileInterval is a duration like "1h", roles are reconciled with reactions at this interval when set.
+// RemoveRolesWithoutReaction allows to remove at startup roles of members who have no longer reacted.
+// MaxRolesRemoved is the safety cap, nothing is removed when more roles would be removed at once.
+// DryRunRemoveRoles only logs roles that would be removed.
+// DryRun only logs changes, nothing is sent to Discord, a summary of changes is logged after messages are added and after each reconciliation.
+// AuditChannel receives role changes and failures, AuditBatchInterval is a duration like "10m" to post them as a summary at this interval.
+// RoleQueueSize enables the queue of role changes from reactions, failed changes are retried RoleRetries times after RoleRetryDelay doubled at each retry.
+// RoleQueueMetricsInterval is a duration like "5m", metrics of the queue are logged at this interval when they changed.
+// Greeting greets members joining the server.
 type Configuration struct {
-	Channel  string    `json:"channel"`
-	Messages []Message `json:"messages"`
+	Channel                    string    `json:"channel"`
+	ChannelID                  string    `json:"channel_id"`
+	Messages                   []Message `json:"messages"`
+	Groups                     []Group   `json:"groups"`
+	DeleteUnknownMessages      bool      `json:"delete_unknown_messages"`
+	StateFilename              string    `json:"state_filename"`
+	ScanLimitMessages          int       `json:"scan_limit_messages"`
+	ReconcileInterval          string    `json:"reconcile_interval"`
+	RemoveRolesWithoutReaction bool      `json:"remove_roles_without_reaction"`
+	MaxRolesRemoved            int       `json:"max_roles_removed"`
+	DryRunRemoveRoles          bool      `json:"dry_run_remove_roles"`
+	DryRun                     bool      `json:"dry_run"`
+	AuditChannel               string    `json:"audit_channel"`
+	AuditChannelID             string    `json:"audit_channel_id"`
+	AuditBatchInterval         string    `json:"audit_batch_interval"`
+	RoleQueueSize              int       `json:"role_queue_size"`
+	RoleRetries                int       `json:"role_retries"`
+	RoleRetryDelay             string    `json:"role_retry_delay"`
+	RoleQueueMetricsInterval   string    `json:"role_queue_metrics_interval"`
+	Greeting                   *Greeting `json:"greeting"`
+}
+
+// Group is a struct.
+// In an exclusive group a member can only have one role among all reactions of the messages of the group.
+// Channel is used by messages of the group which do not define their own channel.
+type Group struct {
+	Name      string `json:"name"`
+	Exclusive bool   `json:"exclusive"`
+	Channel   string `json:"channel"`
+	ChannelID string `json:"channel_id"`
 }
 
 // Message is a struct.
+// Role and Emoji define a single emoji→role pair, Reactions allows to define more pairs on the same message.
+// Type defines how members pick roles: with reactions (default), buttons or a select menu.
+// Key identifies the message in channel when title changes, without Key the title is used.
+// Fields, Footer, ThumbnailURL, ImageURL, Author and Timestamp complete the embed, Timestamp is a date like "2024-01-31T18:00:00Z".
+// Texts of the embed can reference {channel:name} and {role:name}, and use {guild.name} and {member_count}, they are resolved at startup.
+// MinAccountAge and MinMembershipAge are durations like "168h", younger accounts or members are refused the role.
+// DirectMessageAgeGate sends the reason of the refusal to the member.
+// CanPurgeReactions removes reactions of members leaving the server, and at startup of members who left, within purge limits.
 type Message struct {
 	ID                               string
-	Title                            string `json:"title"`
-	Description                      string `json:"description"`
-	Role                             string `json:"role"`
-	RoleID                           string
-	Emoji                            string `json:"emoji"`
-	EmojiID                          string
-	CanPurgeReactions                bool `json:"can_purge_reactions"`
-	Color                            int  `json:"color"`
-	PurgeThresholdMembersReacted     int  `json:"purge_threshold_members_reacted"`
-	PurgeBelowCountMembersNotInGuild int  `json:"purge_below_count_members_not_in_guild"`
+	Channel                          string       `json:"channel"`
+	ChannelID                        string       `json:"channel_id"`
+	Key                              string       `json:"key"`
+	Type                             string       `json:"type"`
+	Title                            string       `json:"title"`
+	Description                      string       `json:"description"`
+	Role                             string       `json:"role"`
+	RoleID                           string       `json:"role_id"`
+	Emoji                            string       `json:"emoji"`
+	EmojiID                          string       `json:"emoji_id"`
+	Reactions                        []Reaction   `json:"reactions"`
+	CanPurgeReactions                bool         `json:"can_purge_reactions"`
+	Color                            int          `json:"color"`
+	PurgeThresholdMembersReacted     int          `json:"purge_threshold_members_reacted"`
+	PurgeBelowCountMembersNotInGuild int          `json:"purge_below_count_members_not_in_guild"`
+	Group                            string       `json:"group"`
+	MinAccountAge                    string       `json:"min_account_age"`
+	MinMembershipAge                 string       `json:"min_membership_age"`
+	DirectMessageAgeGate             bool         `json:"direct_message_age_gate"`
+	Fields                           []EmbedField `json:"fields"`
+	Footer                           string       `json:"footer"`
+	ThumbnailURL                     string       `json:"thumbnail_url"`
+	ImageURL                         string       `json:"image_url"`
+	Author                           *EmbedAuthor `json:"author"`
+	Timestamp                        string       `json:"timestamp"`
+	minAccountAge                    time.Duration
+	minMembershipAge                 time.Duration
+	configuredTitle                  string
+}
+
+// Reaction is a struct.
+type Reaction struct {
+	Emoji   string `json:"emoji"`
+	EmojiID string `json:"emoji_id"`
+	Role    string `json:"role"`
+	RoleID  string `json:"role_id"`
+	Label   string `json:"label"`
+}
+
+// reactions returns the emoji→role pair defined by Role and Emoji followed by Reactions.
+func (m Message) reactions() []Reaction {
+	if m.Emoji == "" && m.EmojiID == "" && m.Role == "" && m.RoleID == "" {
+		return m.Reactions
+	}
+
+	return append([]Reaction{{Emoji: m.Emoji, EmojiID: m.EmojiID, Role: m.Role, RoleID: m.RoleID}}, m.Reactions...)
+}
+
+// key returns Key, or Title as configured when Key is empty.
+// Title is rendered with templates and emojis, the configured one does not change when the Discord server changes.
+func (m Message) key() string {
+	if m.Key != "" {
+		return m.Key
+	}
+
+	return m.configuredTitle
+}
+
+// channelOf returns the channel and channel ID of message, then of its group, then of configuration.
+func (config Configuration) channelOf(message Message) (string, string) {
+	if message.Channel != "" || message.ChannelID != "" {
+		return message.Channel, message.ChannelID
+	}
+
+	for _, group := range config.Groups {
+		if group.Name == message.Group && (group.Channel != "" || group.ChannelID != "") {
+			return group.Channel, group.ChannelID
+		}
+	}
+
+	return config.Channel, config.ChannelID
 }
 
 // Manager is a struct.
 type Manager struct {
-	discordSession *discordgo.Session
-	guildName      string
-	guildID        string
-	channelName    string
-	channelID      string
-	messages       []Message
+	discordSession           *discordgo.Session
+	guildName                string
+	guildID                  string
+	messages                 []Message
+	groups                   []Group
+	deleteUnknownMessages    bool
+	stateFilename            string
+	scanLimitMessages        int
+	reconcileInterval        time.Duration
+	stopReconciliation       chan struct{}
+	reconciliationStopped    chan struct{}
+	removeRolesAtStartup     bool
+	maxRolesRemoved          int
+	dryRunRemoveRoles        bool
+	dryRun                   bool
+	dryRunSummary            dryRunSummary
+	auditChannel             string
+	auditChannelID           string
+	auditBatchInterval       time.Duration
+	auditBatch               auditBatch
+	stopAudit                chan struct{}
+	auditStopped             chan struct{}
+	roleQueueSize            int
+	roleRetries              int
+	roleRetryDelay           time.Duration
+	roleQueueMetricsInterval time.Duration
+	roleQueue                *roleQueue
+	greeting                 *Greeting
+	reactionsOfMembers       reactionsOfMembers
 }
 
 // NewWelcomeManager return a Manager.
@@ -70,7 +215,7 @@ func NewWelcomeManager(
 
 	manager.completeConfiguration(config)
 
-	if !manager.hasValidConfigurationAgainstDiscordServer(config) {
+	if !manager.hasValidConfigurationAgainstDiscordServer() {
 		log.Error().
 			Int("step", stepTwoConfiguration).
 			Msg("discord_bot.welcome.configuration_validation_failed")
@@ -84,8 +229,9 @@ func NewWelcomeManager(
 	return manager
 }
 
+//nolint:cyclop,funlen
 func hasValidConfigurationInFile(config Configuration) bool {
-	if config.Channel == "" {
+	if config.Channel == "" && config.ChannelID == "" && len(config.Messages) == 0 {
 		log.Error().
 			Msg("discord_bot.welcome.configuration_empty_channel")
 
@@ -99,7 +245,120 @@ func hasValidConfigurationInFile(config Configuration) bool {
 		return false
 	}
 
+	if config.ScanLimitMessages < 0 {
+		log.Error().
+			Int("scan_limit_messages", config.ScanLimitMessages).
+			Msg("discord_bot.welcome.configuration_invalid_scan_limit_messages")
+
+		return false
+	}
+
+	if config.MaxRolesRemoved < 0 {
+		log.Error().
+			Int("max_roles_removed", config.MaxRolesRemoved).
+			Msg("discord_bot.welcome.configuration_invalid_max_roles_removed")
+
+		return false
+	}
+
+	if config.ReconcileInterval != "" {
+		reconcileInterval, err := time.ParseDuration(config.ReconcileInterval)
+		if err != nil || reconcileInterval <= 0 {
+			log.Error().
+				Str("reconcile_interval", config.ReconcileInterval).
+				Str("help", "Accepted values are positive durations like '30m' or '1h'").
+				Msg("discord_bot.welcome.configuration_invalid_reconcile_interval")
+
+			return false
+		}
+	}
+
+	if config.AuditBatchInterval != "" {
+		auditBatchInterval, err := time.ParseDuration(config.AuditBatchInterval)
+		if err != nil || auditBatchInterval <= 0 {
+			log.Error().
+				Str("audit_batch_interval", config.AuditBatchInterval).
+				Str("help", "Accepted values are positive durations like '10m' or '1h'").
+				Msg("discord_bot.welcome.configuration_invalid_audit_batch_interval")
+
+			return false
+		}
+	}
+
+	if !hasValidRoleQueueInFile(config) {
+		return false
+	}
+
+	if !hasValidGroupsInFile(config.Groups) {
+		return false
+	}
+
+	if !hasValidGreetingInFile(config.Greeting) {
+		return false
+	}
+
+	keysSeen := make(map[string]struct{}, len(config.Messages))
+
 	for idx, message := range config.Messages {
+		if channel, channelID := config.channelOf(message); channel == "" && channelID == "" {
+			log.Error().
+				Int("message index", idx).
+				Msg("discord_bot.welcome.configuration_empty_channel")
+
+			return false
+		}
+
+		if message.Key != "" {
+			if _, found := keysSeen[message.Key]; found {
+				log.Error().
+					Int("message index", idx).
+					Str("key", message.Key).
+					Msg("discord_bot.welcome.configuration_duplicate_key_message")
+
+				return false
+			}
+
+			keysSeen[message.Key] = struct{}{}
+		}
+
+		if message.Group != "" && !slices.ContainsFunc(config.Groups, func(group Group) bool { return group.Name == message.Group }) {
+			log.Error().
+				Int("message index", idx).
+				Str("group", message.Group).
+				Msg("discord_bot.welcome.configuration_unknown_group_message")
+
+			return false
+		}
+
+		if !slices.Contains([]string{"", messageTypeReactions, messageTypeButtons, messageTypeSelectMenu}, message.Type) {
+			log.Error().
+				Int("message index", idx).
+				Str("type", message.Type).
+				Str("help", "Accepted values are 'reactions', 'buttons' or 'select_menu'").
+				Msg("discord_bot.welcome.configuration_invalid_type_message")
+
+			return false
+		}
+
+		if message.usesComponents() && len(message.reactions()) > limitComponents {
+			log.Error().
+				Int("message index", idx).
+				Int("limit", limitComponents).
+				Msg("discord_bot.welcome.configuration_too_many_components_message")
+
+			return false
+		}
+
+		if message.Key == "" && (strings.Contains(message.Title, placeholderGuildName) || strings.Contains(message.Title, placeholderMemberCount)) {
+			log.Error().
+				Int("message index", idx).
+				Str("title", message.Title).
+				Str("help", "Set key, the message is found by its title which changes with {guild.name} and {member_count}").
+				Msg("discord_bot.welcome.configuration_variable_in_title_without_key_message")
+
+			return false
+		}
+
 		if message.Title == "" && message.Description == "" {
 			log.Error().
 				Int("message index", idx).
@@ -110,21 +369,176 @@ func hasValidConfigurationInFile(config Configuration) bool {
 			return false
 		}
 
-		if message.Emoji == "" {
+		if !hasValidEmbedInFile(idx, message) {
+			return false
+		}
+
+		if !hasValidAgeGateInFile(idx, message) {
+			return false
+		}
+
+		if !hasValidReactionsInFile(idx, message.reactions()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasValidRoleQueueInFile(config Configuration) bool {
+	if config.RoleQueueSize < 0 {
+		log.Error().
+			Int("role_queue_size", config.RoleQueueSize).
+			Msg("discord_bot.welcome.configuration_invalid_role_queue_size")
+
+		return false
+	}
+
+	if config.RoleRetries < 0 {
+		log.Error().
+			Int("role_retries", config.RoleRetries).
+			Msg("discord_bot.welcome.configuration_invalid_role_retries")
+
+		return false
+	}
+
+	if config.RoleRetryDelay != "" {
+		roleRetryDelay, err := time.ParseDuration(config.RoleRetryDelay)
+		if err != nil || roleRetryDelay <= 0 {
 			log.Error().
-				Int("message index", idx).
+				Str("role_retry_delay", config.RoleRetryDelay).
+				Str("help", "Accepted values are positive durations like '500ms' or '1s'").
+				Msg("discord_bot.welcome.configuration_invalid_role_retry_delay")
+
+			return false
+		}
+	}
+
+	if config.RoleQueueMetricsInterval != "" {
+		roleQueueMetricsInterval, err := time.ParseDuration(config.RoleQueueMetricsInterval)
+		if err != nil || roleQueueMetricsInterval <= 0 {
+			log.Error().
+				Str("role_queue_metrics_interval", config.RoleQueueMetricsInterval).
+				Str("help", "Accepted values are positive durations like '1m' or '1h'").
+				Msg("discord_bot.welcome.configuration_invalid_role_queue_metrics_interval")
+
+			return false
+		}
+	}
+
+	return true
+}
+
+func hasValidAgeGateInFile(idxMessage int, message Message) bool {
+	if !isValidAge(message.MinAccountAge) {
+		log.Error().
+			Int("message index", idxMessage).
+			Str("min_account_age", message.MinAccountAge).
+			Str("help", "Accepted values are positive durations like '24h' or '168h'").
+			Msg("discord_bot.welcome.configuration_invalid_min_account_age_message")
+
+		return false
+	}
+
+	if !isValidAge(message.MinMembershipAge) {
+		log.Error().
+			Int("message index", idxMessage).
+			Str("min_membership_age", message.MinMembershipAge).
+			Str("help", "Accepted values are positive durations like '24h' or '168h'").
+			Msg("discord_bot.welcome.configuration_invalid_min_membership_age_message")
+
+		return false
+	}
+
+	return true
+}
+
+// isValidAge returns true when age is empty or a positive duration.
+func isValidAge(age string) bool {
+	if age == "" {
+		return true
+	}
+
+	duration, err := time.ParseDuration(age)
+
+	return err == nil && duration > 0
+}
+
+func hasValidGroupsInFile(groups []Group) bool {
+	groupsSeen := make(map[string]struct{}, len(groups))
+
+	for idx, group := range groups {
+		if group.Name == "" {
+			log.Error().
+				Int("group index", idx).
+				Msg("discord_bot.welcome.configuration_empty_group_name")
+
+			return false
+		}
+
+		_, exists := groupsSeen[group.Name]
+		if exists {
+			log.Error().
+				Int("group index", idx).
+				Str("group", group.Name).
+				Msg("discord_bot.welcome.configuration_duplicate_group")
+
+			return false
+		}
+
+		groupsSeen[group.Name] = struct{}{}
+	}
+
+	return true
+}
+
+func hasValidReactionsInFile(idxMessage int, reactions []Reaction) bool {
+	if len(reactions) == 0 {
+		log.Error().
+			Int("message index", idxMessage).
+			Msg("discord_bot.welcome.configuration_empty_emoji_message")
+
+		return false
+	}
+
+	emojisSeen := make(map[string]struct{}, len(reactions))
+
+	for idxReaction, reaction := range reactions {
+		if reaction.Emoji == "" && reaction.EmojiID == "" {
+			log.Error().
+				Int("message index", idxMessage).
+				Int("reaction index", idxReaction).
 				Msg("discord_bot.welcome.configuration_empty_emoji_message")
 
 			return false
 		}
 
-		if message.Role == "" {
+		if reaction.Role == "" && reaction.RoleID == "" {
 			log.Error().
-				Int("message index", idx).
+				Int("message index", idxMessage).
+				Int("reaction index", idxReaction).
 				Msg("discord_bot.welcome.configuration_empty_role_message")
 
 			return false
 		}
+
+		emoji := reaction.Emoji
+		if reaction.EmojiID != "" {
+			emoji = reaction.EmojiID
+		}
+
+		_, exists := emojisSeen[emoji]
+		if exists {
+			log.Error().
+				Int("message index", idxMessage).
+				Int("reaction index", idxReaction).
+				Str("emoji", reaction.Emoji).
+				Msg("discord_bot.welcome.configuration_duplicate_emoji_message")
+
+			return false
+		}
+
+		emojisSeen[emoji] = struct{}{}
 	}
 
 	return true
@@ -133,6 +547,74 @@ func hasValidConfigurationInFile(config Configuration) bool {
 //nolint:cyclop,funlen
 func (w *Manager) completeConfiguration(config Configuration) {
 	w.messages = append(make([]Message, 0, len(config.Messages)), config.Messages...)
+	w.groups = append(make([]Group, 0, len(config.Groups)), config.Groups...)
+	w.deleteUnknownMessages = config.DeleteUnknownMessages
+	w.stateFilename = config.StateFilename
+
+	for idx := range w.messages {
+		w.messages[idx].configuredTitle = w.messages[idx].Title
+	}
+
+	w.scanLimitMessages = config.ScanLimitMessages
+	if w.scanLimitMessages == 0 {
+		w.scanLimitMessages = defaultScanLimitMessages
+	}
+
+	w.removeRolesAtStartup = config.RemoveRolesWithoutReaction
+	w.dryRunRemoveRoles = config.DryRunRemoveRoles
+	w.dryRun = config.DryRun
+
+	w.maxRolesRemoved = config.MaxRolesRemoved
+	if w.maxRolesRemoved == 0 {
+		w.maxRolesRemoved = defaultMaxRolesRemoved
+	}
+
+	if config.ReconcileInterval != "" {
+		w.reconcileInterval, _ = time.ParseDuration(config.ReconcileInterval)
+	}
+
+	w.auditChannel = config.AuditChannel
+	w.auditChannelID = config.AuditChannelID
+
+	if config.AuditBatchInterval != "" {
+		w.auditBatchInterval, _ = time.ParseDuration(config.AuditBatchInterval)
+	}
+
+	w.roleQueueSize = config.RoleQueueSize
+
+	w.roleRetries = config.RoleRetries
+	if w.roleRetries == 0 {
+		w.roleRetries = defaultRoleRetries
+	}
+
+	w.roleRetryDelay = defaultRoleRetryDelay
+	if config.RoleRetryDelay != "" {
+		w.roleRetryDelay, _ = time.ParseDuration(config.RoleRetryDelay)
+	}
+
+	w.roleQueueMetricsInterval = defaultRoleQueueMetricsInterval
+	if config.RoleQueueMetricsInterval != "" {
+		w.roleQueueMetricsInterval, _ = time.ParseDuration(config.RoleQueueMetricsInterval)
+	}
+
+	if config.Greeting != nil {
+		greeting := *config.Greeting
+		w.greeting = &greeting
+	}
+
+	for idx := range w.messages {
+		w.messages[idx].Channel, w.messages[idx].ChannelID = config.channelOf(w.messages[idx])
+		w.messages[idx].Reactions = w.messages[idx].reactions()
+		w.messages[idx].Fields = slices.Clone(w.messages[idx].Fields)
+
+		if w.messages[idx].MinAccountAge != "" {
+			w.messages[idx].minAccountAge, _ = time.ParseDuration(w.messages[idx].MinAccountAge)
+		}
+
+		if w.messages[idx].MinMembershipAge != "" {
+			w.messages[idx].minMembershipAge, _ = time.ParseDuration(w.messages[idx].MinMembershipAge)
+		}
+	}
 
 	for _, guild := range w.discordSession.State.Guilds {
 		if guild.Name != w.guildName {
@@ -146,35 +628,70 @@ func (w *Manager) completeConfiguration(config Configuration) {
 
 		w.guildID = guild.ID
 
-		for _, channel := range guild.Channels {
-			if channel.Name != config.Channel {
+		channelIDsSet := []string{}
+
+		for idx := range w.messages {
+			channels := findChannels(guild, w.messages[idx].ChannelID, w.messages[idx].Channel)
+			if len(channels) != 1 {
 				continue
 			}
 
-			log.Info().
-				Str("channel_id", channel.ID).
-				Str("channel", config.Channel).
-				Msg("discord_bot.welcome.set_channel_id")
+			if !slices.Contains(channelIDsSet, channels[0].ID) {
+				log.Info().
+					Str("channel_id", channels[0].ID).
+					Str("channel", channels[0].Name).
+					Msg("discord_bot.welcome.set_channel_id")
+
+				channelIDsSet = append(channelIDsSet, channels[0].ID)
+			}
 
-			w.channelID = channel.ID
-			w.channelName = config.Channel
+			w.messages[idx].ChannelID = channels[0].ID
+			w.messages[idx].Channel = channels[0].Name
+		}
 
-			break
+		if w.auditChannel != "" || w.auditChannelID != "" {
+			channels := findChannels(guild, w.auditChannelID, w.auditChannel)
+			if len(channels) == 1 {
+				log.Info().
+					Str("channel_id", channels[0].ID).
+					Str("channel", channels[0].Name).
+					Msg("discord_bot.welcome.set_audit_channel_id")
+
+				w.auditChannelID = channels[0].ID
+				w.auditChannel = channels[0].Name
+			}
+		}
+
+		if w.greeting != nil {
+			w.greeting.resolveChannels(guild)
+		}
+
+		for idx := range w.messages {
+			for _, text := range w.messages[idx].texts() {
+				*text = renderTemplate(guild, *text)
+			}
 		}
 
 		for _, role := range guild.Roles {
 			for idx := range w.messages {
-				if w.messages[idx].Role != role.Name {
-					continue
+				for idxReaction := range w.messages[idx].Reactions {
+					reaction := &w.messages[idx].Reactions[idxReaction]
+
+					roles := findRoles(guild, reaction.RoleID, reaction.Role)
+					if len(roles) != 1 || roles[0].ID != role.ID {
+						continue
+					}
+
+					log.Info().
+						Int("message index", idx).
+						Int("reaction index", idxReaction).
+						Str("role_id", role.ID).
+						Str("role", role.Name).
+						Msg("discord_bot.welcome.set_role_id")
+
+					reaction.RoleID = role.ID
+					reaction.Role = role.Name
 				}
-
-				log.Info().
-					Int("message index", idx).
-					Str("role_id", role.ID).
-					Str("role", w.messages[idx].Role).
-					Msg("discord_bot.welcome.set_role_id")
-
-				w.messages[idx].RoleID = role.ID
 			}
 		}
 
@@ -182,30 +699,71 @@ func (w *Manager) completeConfiguration(config Configuration) {
 			emojiRichEmbed := fmt.Sprintf("<:%s:%s>", emoji.Name, emoji.ID)
 			emojiInText := ":" + emoji.Name + ":"
 
-			for idx := range w.messages {
-				w.messages[idx].Title = strings.ReplaceAll(w.messages[idx].Title, emojiInText, emojiRichEmbed)
-				w.messages[idx].Description = strings.ReplaceAll(w.messages[idx].Description, emojiInText, emojiRichEmbed)
+			if w.greeting != nil {
+				w.greeting.Message = strings.ReplaceAll(w.greeting.Message, emojiInText, emojiRichEmbed)
+				w.greeting.DirectMessage = strings.ReplaceAll(w.greeting.DirectMessage, emojiInText, emojiRichEmbed)
+			}
 
-				if w.messages[idx].Emoji != emoji.Name {
-					continue
+			for idx := range w.messages {
+				for _, text := range w.messages[idx].texts() {
+					*text = strings.ReplaceAll(*text, emojiInText, emojiRichEmbed)
 				}
 
-				log.Info().
-					Int("message index", idx).
-					Str("emoji_id", emoji.ID).
-					Str("emoji", w.messages[idx].Emoji).
-					Msg("discord_bot.welcome.set_emoji_id")
+				for idxReaction := range w.messages[idx].Reactions {
+					reaction := &w.messages[idx].Reactions[idxReaction]
+
+					emojis := findEmojis(guild, reaction.EmojiID, reaction.Emoji)
+					if len(emojis) != 1 || emojis[0].ID != emoji.ID {
+						continue
+					}
 
-				w.messages[idx].EmojiID = emoji.ID
+					log.Info().
+						Int("message index", idx).
+						Int("reaction index", idxReaction).
+						Str("emoji_id", emoji.ID).
+						Str("emoji", emoji.Name).
+						Msg("discord_bot.welcome.set_emoji_id")
+
+					reaction.EmojiID = emoji.ID
+					reaction.Emoji = emoji.Name
+				}
 			}
 		}
 
 		break
 	}
+
+	for idx := range w.messages {
+		for idxReaction, reaction := range w.messages[idx].Reactions {
+			if reaction.EmojiID != "" || !isUnicodeEmoji(reaction.Emoji) {
+				continue
+			}
+
+			log.Info().
+				Int("message index", idx).
+				Int("reaction index", idxReaction).
+				Str("emoji", reaction.Emoji).
+				Msg("discord_bot.welcome.set_unicode_emoji")
+		}
+	}
+}
+
+// channelIDs returns channel IDs of messages without duplicates, following configuration order.
+func (w *Manager) channelIDs() []string {
+	channelIDs := []string{}
+
+	for _, message := range w.messages {
+		if !slices.Contains(channelIDs, message.ChannelID) {
+			channelIDs = append(channelIDs, message.ChannelID)
+		}
+	}
+
+	return channelIDs
 }
 
-func (w *Manager) hasValidConfigurationAgainstDiscordServer(config Configuration) bool {
-	if w.guildID == "" {
+func (w *Manager) hasValidConfigurationAgainstDiscordServer() bool {
+	idxGuild := slices.IndexFunc(w.discordSession.State.Guilds, func(guild *discordgo.Guild) bool { return guild.ID == w.guildID })
+	if w.guildID == "" || idxGuild == -1 {
 		log.Error().
 			Str("guild", w.guildName).
 			Msg("discord_bot.welcome.configuration_guild_missed")
@@ -213,33 +771,142 @@ func (w *Manager) hasValidConfigurationAgainstDiscordServer(config Configuration
 		return false
 	}
 
-	if w.channelID == "" {
-		log.Error().
-			Str("channel", config.Channel).
-			Msg("discord_bot.welcome.configuration_channel_missed")
+	guild := w.discordSession.State.Guilds[idxGuild]
+
+	for _, message := range w.messages {
+		if !hasValidChannelAgainstDiscordServer(guild, message.ChannelID, message.Channel) {
+			return false
+		}
+	}
 
+	if (w.auditChannel != "" || w.auditChannelID != "") && !hasValidChannelAgainstDiscordServer(guild, w.auditChannelID, w.auditChannel) {
 		return false
 	}
 
-	for idx, message := range w.messages {
-		if message.EmojiID == "" {
-			log.Error().
-				Int("message index", idx).
-				Str("emoji", message.Emoji).
-				Msg("discord_bot.welcome.configuration_emoji_missed")
+	if w.greeting != nil && !w.greeting.hasValidChannelsAgainstDiscordServer(guild) {
+		return false
+	}
 
+	for idx, message := range w.messages {
+		if !hasValidTemplatesAgainstDiscordServer(guild, idx, message) {
 			return false
 		}
+	}
 
-		if message.RoleID == "" {
-			log.Error().
-				Int("message index", idx).
-				Str("role", message.Role).
-				Msg("discord_bot.welcome.configuration_role_missed")
+	for idx, message := range w.messages {
+		for idxReaction, reaction := range message.Reactions {
+			if !hasValidEmojiAgainstDiscordServer(guild, idx, idxReaction, reaction) {
+				return false
+			}
 
-			return false
+			if !hasValidRoleAgainstDiscordServer(guild, idx, idxReaction, reaction) {
+				return false
+			}
 		}
 	}
 
+	return w.hasPermissionsAgainstDiscordServer(guild)
+}
+
+func hasValidChannelAgainstDiscordServer(guild *discordgo.Guild, channelID string, channel string) bool {
+	channels := findChannels(guild, channelID, channel)
+
+	switch {
+	case len(channels) > 1:
+		log.Error().
+			Str("channel", channel).
+			Int("count_channels", len(channels)).
+			Str("help", "Several channels have this name, use channel_id instead").
+			Msg("discord_bot.welcome.configuration_channel_ambiguous")
+
+		return false
+	case len(channels) == 0 && channelID != "":
+		log.Error().
+			Str("channel_id", channelID).
+			Msg("discord_bot.welcome.configuration_channel_id_missed")
+
+		return false
+	case len(channels) == 0:
+		log.Error().
+			Str("channel", channel).
+			Msg("discord_bot.welcome.configuration_channel_missed")
+
+		return false
+	}
+
+	return true
+}
+
+func hasValidEmojiAgainstDiscordServer(guild *discordgo.Guild, idxMessage int, idxReaction int, reaction Reaction) bool {
+	if reaction.EmojiID == "" && isUnicodeEmoji(reaction.Emoji) {
+		return true
+	}
+
+	emojis := findEmojis(guild, reaction.EmojiID, reaction.Emoji)
+
+	switch {
+	case len(emojis) > 1:
+		log.Error().
+			Int("message index", idxMessage).
+			Int("reaction index", idxReaction).
+			Str("emoji", reaction.Emoji).
+			Int("count_emojis", len(emojis)).
+			Str("help", "Several emojis have this name, use emoji_id instead").
+			Msg("discord_bot.welcome.configuration_emoji_ambiguous")
+
+		return false
+	case len(emojis) == 0 && reaction.EmojiID != "":
+		log.Error().
+			Int("message index", idxMessage).
+			Int("reaction index", idxReaction).
+			Str("emoji_id", reaction.EmojiID).
+			Msg("discord_bot.welcome.configuration_emoji_id_missed")
+
+		return false
+	case len(emojis) == 0:
+		log.Error().
+			Int("message index", idxMessage).
+			Int("reaction index", idxReaction).
+			Str("emoji", reaction.Emoji).
+			Msg("discord_bot.welcome.configuration_emoji_missed")
+
+		return false
+	}
+
+	return true
+}
+
+func hasValidRoleAgainstDiscordServer(guild *discordgo.Guild, idxMessage int, idxReaction int, reaction Reaction) bool {
+	roles := findRoles(guild, reaction.RoleID, reaction.Role)
+
+	switch {
+	case len(roles) > 1:
+		log.Error().
+			Int("message index", idxMessage).
+			Int("reaction index", idxReaction).
+			Str("role", reaction.Role).
+			Int("count_roles", len(roles)).
+			Str("help", "Several roles have this name, use role_id instead").
+			Msg("discord_bot.welcome.configuration_role_ambiguous")
+
+		return false
+	case len(roles) == 0 && reaction.RoleID != "":
+		log.Error().
+			Int("message index", idxMessage).
+			Int("reaction index", idxReaction).
+			Str("role_id", reaction.RoleID).
+			Msg("discord_bot.welcome.configuration_role_id_missed")
+
+		return false
+	case len(roles) == 0:
+		log.Error().
+			Int("message index", idxMessage).
+			Int("reaction index", idxReaction).
+			Str("role", reaction.Role).
+			Msg("discord_bot.welcome.configuration_role_missed")
+
+		return false
+	}
+
 	return true
 }
@@ -0,0 +1,102 @@
+package welcome
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	replyAccountTooYoung    = "Sorry, your Discord account must be at least %s old to get the role **%s**."
+	replyMembershipTooYoung = "Sorry, you must be a member of the server for at least %s to get the role **%s**."
+)
+
+// ageGateRefusal returns why the user cannot get the role of reaction yet, or an empty string when the user can get it.
+// Account age comes from the user ID, membership age from member, or from state when member is nil.
+// When the join date is unknown, the user is refused.
+func (w *Manager) ageGateRefusal(message Message, reaction Reaction, userID string, member *discordgo.Member) string {
+	if message.minAccountAge > 0 {
+		createdAt, err := discordgo.SnowflakeTimestamp(userID)
+		if err == nil && time.Since(createdAt) < message.minAccountAge {
+			return fmt.Sprintf(replyAccountTooYoung, message.MinAccountAge, reaction.Role)
+		}
+	}
+
+	if message.minMembershipAge > 0 {
+		if member == nil {
+			member, _ = w.discordSession.State.Member(w.guildID, userID)
+		}
+
+		if member == nil || member.JoinedAt.IsZero() || time.Since(member.JoinedAt) < message.minMembershipAge {
+			return fmt.Sprintf(replyMembershipTooYoung, message.MinMembershipAge, reaction.Role)
+		}
+	}
+
+	return ""
+}
+
+// refuseReaction removes the reaction of the user who cannot get the role yet, the reason is sent by DM if withDirectMessage is true.
+func (w *Manager) refuseReaction(message Message, reaction Reaction, userID string, refusal string, withDirectMessage bool) {
+	log.Info().
+		Str("role_id", reaction.RoleID).
+		Str("role", reaction.Role).
+		Str("message_id", message.ID).
+		Str("user_id", userID).
+		Str("reason", refusal).
+		Msg("discord_bot.welcome.user_role_refused")
+
+	log.Info().
+		Str("message_id", message.ID).
+		Str("emoji", reaction.emojiAPIName()).
+		Str("user_id", userID).
+		Msg("discord_bot.welcome.refused_reaction_removing")
+
+	if !w.skipInDryRun(changeReactionRemove) {
+		err := w.discordSession.MessageReactionRemove(message.ChannelID, message.ID, reaction.emojiAPIName(), userID)
+		if err != nil {
+			log.Error().Err(err).
+				Str("message_id", message.ID).
+				Str("emoji", reaction.emojiAPIName()).
+				Str("user_id", userID).
+				Msg("discord_bot.welcome.refused_reaction_removing_failed")
+		}
+	}
+
+	if withDirectMessage {
+		w.sendDirectMessage(userID, refusal)
+	}
+}
+
+func (w *Manager) sendDirectMessage(userID string, content string) {
+	log.Info().
+		Str("user_id", userID).
+		Msg("discord_bot.welcome.sending_direct_message")
+
+	if w.skipInDryRun(changeDirectMessage) {
+		return
+	}
+
+	channel, err := w.discordSession.UserChannelCreate(userID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("user_id", userID).
+			Msg("discord_bot.welcome.direct_message_sending_failed")
+
+		return
+	}
+
+	_, err = w.discordSession.ChannelMessageSend(channel.ID, content)
+	if err != nil {
+		log.Error().Err(err).
+			Str("user_id", userID).
+			Msg("discord_bot.welcome.direct_message_sending_failed")
+
+		return
+	}
+
+	log.Info().
+		Str("user_id", userID).
+		Msg("discord_bot.welcome.direct_message_sent")
+}
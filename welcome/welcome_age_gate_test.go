@@ -0,0 +1,202 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	discordEpoch = 1420070400000
+	oldAccountID = "80351110224678912"
+	ageGateHelp  = "Accepted values are positive durations like '24h' or '168h'"
+)
+
+// createSnowflake returns a user ID created at createdAt.
+func createSnowflake(createdAt time.Time) string {
+	return strconv.FormatInt((createdAt.UnixMilli()-discordEpoch)<<22, 10)
+}
+
+func TestNewWelcomeManager_ErrorAgeGate(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:  "my-channel",
+		Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1", MinAccountAge: "7 days"}},
+	}, guildName, session)
+	require.Nil(t, welcomeManager)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"error","message index":0,"min_account_age":"7 days","help":"`+ageGateHelp+`","message":"discord_bot.welcome.configuration_invalid_min_account_age_message"}`, parts[1])
+
+	bufferLogs.Reset()
+
+	welcomeManager = welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:  "my-channel",
+		Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1", MinMembershipAge: "-1h"}},
+	}, guildName, session)
+	require.Nil(t, welcomeManager)
+
+	parts = strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"error","message index":0,"min_membership_age":"-1h","help":"`+ageGateHelp+`","message":"discord_bot.welcome.configuration_invalid_min_membership_age_message"}`, parts[1])
+}
+
+//nolint:funlen
+func TestHandlers_OnMessageReactionAdd_AgeGate(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{
+				Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1",
+				MinAccountAge: "168h", MinMembershipAge: "1h", DirectMessageAgeGate: true,
+			},
+		},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	session.Client = createClient(t,
+		[]*http.Response{
+			createJSONResponse(t, []*discordgo.Message{
+				{ID: "200", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+			}),
+			createJSONResponse(t, []discordgo.User{}),
+		},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+		},
+	)
+
+	err = welcomeManager.Run()
+	require.NoError(t, err)
+
+	t.Run("should refuse role, remove reaction and send direct message because account is too young", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		youngAccountID := createSnowflake(time.Now().Add(-time.Hour))
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createEmptyResponse(t),
+				createJSONResponse(t, discordgo.Channel{ID: "dm-123"}),
+				createJSONResponse(t, discordgo.Message{ID: "300"}),
+			},
+			[]requestTest{
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123/" + youngAccountID},
+				{method: "POST", host: "discord.com", uri: "/api/v9/users/@me/channels", body: `{"recipient_id":"` + youngAccountID + `"}`},
+				{
+					method: "POST", host: "discord.com", uri: "/api/v9/channels/dm-123/messages",
+					body: `{"content":"Sorry, your Discord account must be at least 168h old to get the role **my role 1**.","embeds":null,"tts":false,"components":null,"sticker_ids":null}`,
+				},
+			},
+		)
+
+		welcomeManager.OnMessageReactionAdd(session, &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{
+				UserID:    youngAccountID,
+				MessageID: "200",
+				Emoji:     discordgo.Emoji{ID: "emoji-123", Name: "my-emoji-1"},
+				ChannelID: "channel-123",
+				GuildID:   "guild-123",
+			},
+			Member: &discordgo.Member{JoinedAt: time.Now().Add(-48 * time.Hour)},
+		})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","message_id":"200","user_id":"`+youngAccountID+`","reason":"Sorry, your Discord account must be at least 168h old to get the role **my role 1**.","message":"discord_bot.welcome.user_role_refused"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","message_id":"200","emoji":"my-emoji-1:emoji-123","user_id":"`+youngAccountID+`","message":"discord_bot.welcome.refused_reaction_removing"}`, parts[2])
+		require.JSONEq(t, `{"level":"info","user_id":"`+youngAccountID+`","message":"discord_bot.welcome.sending_direct_message"}`, parts[3])
+		require.JSONEq(t, `{"level":"info","user_id":"`+youngAccountID+`","message":"discord_bot.welcome.direct_message_sent"}`, parts[4])
+		require.Empty(t, parts[5])
+	})
+
+	t.Run("should refuse role because member joined recently", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{createEmptyResponse(t), createErrorResponse(t)},
+			[]requestTest{
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123/" + oldAccountID},
+				{method: "POST", host: "discord.com", uri: "/api/v9/users/@me/channels", body: `{"recipient_id":"` + oldAccountID + `"}`},
+			},
+		)
+
+		welcomeManager.OnMessageReactionAdd(session, &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{
+				UserID:    oldAccountID,
+				MessageID: "200",
+				Emoji:     discordgo.Emoji{ID: "emoji-123", Name: "my-emoji-1"},
+				ChannelID: "channel-123",
+				GuildID:   "guild-123",
+			},
+			Member: &discordgo.Member{JoinedAt: time.Now().Add(-time.Minute)},
+		})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","message_id":"200","user_id":"`+oldAccountID+`","reason":"Sorry, you must be a member of the server for at least 1h to get the role **my role 1**.","message":"discord_bot.welcome.user_role_refused"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","error":"HTTP 500 Internal Server Error, ","user_id":"`+oldAccountID+`","message":"discord_bot.welcome.direct_message_sending_failed"}`, parts[4])
+		require.Empty(t, parts[5])
+	})
+
+	t.Run("should add role because account and membership are old enough", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{createEmptyResponse(t)},
+			[]requestTest{
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/" + oldAccountID + "/roles/role-123"},
+			},
+		)
+
+		welcomeManager.OnMessageReactionAdd(session, &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{
+				UserID:    oldAccountID,
+				MessageID: "200",
+				Emoji:     discordgo.Emoji{ID: "emoji-123", Name: "my-emoji-1"},
+				ChannelID: "channel-123",
+				GuildID:   "guild-123",
+			},
+			Member: &discordgo.Member{JoinedAt: time.Now().Add(-48 * time.Hour)},
+		})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","channel_id":"channel-123","message_id":"200","user_id":"`+oldAccountID+`","message":"discord_bot.welcome.user_role_added"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+}
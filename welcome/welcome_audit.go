@@ -0,0 +1,214 @@
+package welcome
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	auditRoleAdded          string = "added"
+	auditRoleRemoved        string = "removed"
+	auditRoleAddingFailed   string = "adding_failed"
+	auditRoleRemovingFailed string = "removing_failed"
+
+	colorAuditSuccess     int = 0x2ECC71
+	colorAuditFailure     int = 0xE74C3C
+	colorAuditBatch       int = 0x3498DB
+	limitEmbedDescription int = 4096
+)
+
+// auditEntry is a role change, or a failed role change, posted in the audit channel.
+type auditEntry struct {
+	action string
+	userID string
+	roleID string
+	err    error
+}
+
+func (e auditEntry) title() string {
+	switch e.action {
+	case auditRoleAdded:
+		return "Role added"
+	case auditRoleRemoved:
+		return "Role removed"
+	case auditRoleAddingFailed:
+		return "Role adding failed"
+	default:
+		return "Role removing failed"
+	}
+}
+
+// line returns a compact text of the change, mentions are not notified in embeds.
+func (e auditEntry) line() string {
+	var text string
+
+	switch e.action {
+	case auditRoleAdded:
+		text = fmt.Sprintf("➕ <@&%s> to <@%s>", e.roleID, e.userID)
+	case auditRoleRemoved:
+		text = fmt.Sprintf("➖ <@&%s> from <@%s>", e.roleID, e.userID)
+	case auditRoleAddingFailed:
+		text = fmt.Sprintf("❌ <@&%s> not added to <@%s>", e.roleID, e.userID)
+	default:
+		text = fmt.Sprintf("❌ <@&%s> not removed from <@%s>", e.roleID, e.userID)
+	}
+
+	if e.err != nil {
+		text += ": " + e.err.Error()
+	}
+
+	return text
+}
+
+// auditBatch keeps entries until they are posted, role changes come from handlers and reconciliation goroutines.
+type auditBatch struct {
+	mutex   sync.Mutex
+	entries []auditEntry
+}
+
+// audit posts the role change in the audit channel, or keeps it for the next batch when auditBatchInterval is set.
+// Embeds have no timestamp, the date of the message is the date of the change.
+func (w *Manager) audit(action string, userID string, roleID string, err error) {
+	if w.auditChannelID == "" {
+		return
+	}
+
+	entry := auditEntry{action: action, userID: userID, roleID: roleID, err: err}
+
+	if w.auditBatchInterval > 0 {
+		w.auditBatch.mutex.Lock()
+		w.auditBatch.entries = append(w.auditBatch.entries, entry)
+		w.auditBatch.mutex.Unlock()
+
+		return
+	}
+
+	color := colorAuditSuccess
+	if entry.err != nil {
+		color = colorAuditFailure
+	}
+
+	w.sendAuditEmbed(&discordgo.MessageEmbed{
+		Title:       entry.title(),
+		Description: entry.line(),
+		Color:       color,
+	})
+}
+
+// flushAudit posts entries kept since the last batch, in as many embeds as needed.
+func (w *Manager) flushAudit() {
+	w.auditBatch.mutex.Lock()
+	entries := w.auditBatch.entries
+	w.auditBatch.entries = nil
+	w.auditBatch.mutex.Unlock()
+
+	if len(entries) == 0 {
+		return
+	}
+
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, entry.line())
+	}
+
+	for _, description := range chunkLines(lines, limitEmbedDescription) {
+		w.sendAuditEmbed(&discordgo.MessageEmbed{
+			Title:       fmt.Sprintf("Role changes (%d)", len(entries)),
+			Description: description,
+			Color:       colorAuditBatch,
+		})
+	}
+}
+
+// chunkLines joins lines in texts not longer than limit, a line longer than limit is cut.
+func chunkLines(lines []string, limit int) []string {
+	chunks := []string{}
+	current := ""
+
+	for _, line := range lines {
+		if len(line) > limit {
+			line = line[:limit]
+		}
+
+		if current != "" && len(current)+1+len(line) > limit {
+			chunks = append(chunks, current)
+			current = ""
+		}
+
+		if current != "" {
+			current += "\n"
+		}
+
+		current += line
+	}
+
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+
+	return chunks
+}
+
+func (w *Manager) sendAuditEmbed(embed *discordgo.MessageEmbed) {
+	_, err := w.discordSession.ChannelMessageSendEmbed(w.auditChannelID, embed)
+	if err != nil {
+		log.Error().Err(err).
+			Str("channel_id", w.auditChannelID).
+			Str("channel", w.auditChannel).
+			Msg("discord_bot.welcome.audit_sending_failed")
+	}
+}
+
+func (w *Manager) startAuditBatch() {
+	if w.auditChannelID == "" || w.auditBatchInterval <= 0 || w.stopAudit != nil {
+		return
+	}
+
+	log.Info().
+		Str("audit_batch_interval", w.auditBatchInterval.String()).
+		Msg("discord_bot.welcome.starting_audit_batch")
+
+	w.stopAudit = make(chan struct{})
+	w.auditStopped = make(chan struct{})
+
+	go func(stopAudit chan struct{}, auditStopped chan struct{}) {
+		defer close(auditStopped)
+
+		ticker := time.NewTicker(w.auditBatchInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopAudit:
+				w.flushAudit()
+
+				return
+			case <-ticker.C:
+				w.flushAudit()
+			}
+		}
+	}(w.stopAudit, w.auditStopped)
+}
+
+// stopAuditBatch posts remaining entries before stopping.
+func (w *Manager) stopAuditBatch() {
+	if w.stopAudit == nil {
+		return
+	}
+
+	log.Info().
+		Msg("discord_bot.welcome.stopping_audit_batch")
+
+	close(w.stopAudit)
+	<-w.auditStopped
+
+	w.stopAudit = nil
+	w.auditStopped = nil
+
+	log.Info().
+		Msg("discord_bot.welcome.audit_batch_stopped")
+}
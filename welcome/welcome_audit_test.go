@@ -0,0 +1,222 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWelcomeManager_ErrorAuditBatchInterval(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:            "my-channel",
+		Messages:           []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+		AuditChannel:       "my-audit-channel",
+		AuditBatchInterval: "-10m",
+	}, guildName, session)
+	require.Nil(t, welcomeManager)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+	require.JSONEq(t, `{"level":"error","audit_batch_interval":"-10m","help":"Accepted values are positive durations like '10m' or '1h'","message":"discord_bot.welcome.configuration_invalid_audit_batch_interval"}`, parts[1])
+	require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+	require.Empty(t, parts[3])
+}
+
+func TestNewWelcomeManager_ErrorAuditChannelMissed(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+	})
+	require.NoError(t, err)
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:      "my-channel",
+		Messages:     []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+		AuditChannel: "my-audit-channel",
+	}, guildName, session)
+	require.Nil(t, welcomeManager)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"error","channel":"my-audit-channel","message":"discord_bot.welcome.configuration_channel_missed"}`, parts[len(parts)-3])
+	require.JSONEq(t, `{"level":"error","step":2,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[len(parts)-2])
+	require.Empty(t, parts[len(parts)-1])
+}
+
+func TestNewWelcomeManager_ErrorAuditChannelPermission(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:   "guild-123",
+		Name: guildName,
+		Channels: []*discordgo.Channel{
+			{ID: "channel-123", Name: "my-channel"},
+			{ID: "channel-audit", Name: "my-audit-channel", PermissionOverwrites: []*discordgo.PermissionOverwrite{
+				{ID: "guild-123", Type: discordgo.PermissionOverwriteTypeRole, Deny: discordgo.PermissionEmbedLinks},
+			}},
+		},
+		Emojis: []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles: []*discordgo.Role{
+			{ID: "guild-123", Name: "@everyone", Permissions: discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionEmbedLinks | discordgo.PermissionReadMessageHistory | discordgo.PermissionAddReactions},
+			{ID: "role-123", Name: "my role 1", Position: 1},
+			{ID: "role-bot", Name: "bot", Position: 2, Permissions: discordgo.PermissionManageRoles},
+		},
+		Members: []*discordgo.Member{{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:      "my-channel",
+		Messages:     []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+		AuditChannel: "my-audit-channel",
+	}, guildName, session)
+	require.Nil(t, welcomeManager)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"info","channel_id":"channel-audit","channel":"my-audit-channel","message":"discord_bot.welcome.set_audit_channel_id"}`, parts[3])
+	require.JSONEq(t, `{"level":"error","permission":"Embed Links","channel_id":"channel-audit","channel":"my-audit-channel","message":"discord_bot.welcome.configuration_channel_permission_missed"}`, parts[len(parts)-3])
+	require.JSONEq(t, `{"level":"error","step":2,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[len(parts)-2])
+	require.Empty(t, parts[len(parts)-1])
+}
+
+//nolint:funlen
+func TestReconcile_Audit(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}, {ID: "channel-audit", Name: "my-audit-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-123", "role-bot"}},
+			{User: &discordgo.User{ID: "user-id-456"}, Roles: []string{"role-123"}},
+			{User: &discordgo.User{ID: "user-id-789"}, Roles: []string{"role-123"}},
+			{User: &discordgo.User{ID: "user-id-999"}, Roles: []string{}},
+		},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	t.Run("should post each role change in audit channel", func(t *testing.T) {
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:      "my-channel",
+			Messages:     []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1", ID: "200"}},
+			AuditChannel: "my-audit-channel",
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []discordgo.User{{ID: "user-id-456"}, {ID: "user-id-999"}}),
+				createJSONResponse(t, []discordgo.User{}),
+				createEmptyResponse(t),
+				createJSONResponse(t, discordgo.Message{ID: "300"}),
+				createErrorResponse(t),
+				createJSONResponse(t, discordgo.Message{ID: "301"}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?after=user-id-999&limit=100"},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-999/roles/role-123"},
+				{method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-audit/messages", body: `{"embeds":[{"type":"rich","title":"Role added","description":"➕ \u003c@\u0026role-123\u003e to \u003c@user-id-999\u003e","color":3066993}],"tts":false,"components":null,"sticker_ids":null}`},
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-789/roles/role-123"},
+				{method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-audit/messages", body: `{"embeds":[{"type":"rich","title":"Role removing failed","description":"❌ \u003c@\u0026role-123\u003e not removed from \u003c@user-id-789\u003e: HTTP 500 Internal Server Error, ","color":15158332}],"tts":false,"components":null,"sticker_ids":null}`},
+			},
+		)
+
+		welcomeManager.Reconcile()
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","error":"HTTP 500 Internal Server Error, ","role_id":"role-123","role":"my role 1","user_id":"user-id-789","message":"discord_bot.welcome.role_without_reaction_removing_failed"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.roles_reconciled"}`, parts[5])
+		require.Empty(t, parts[6])
+	})
+
+	t.Run("should post role changes in a summary when audit is batched", func(t *testing.T) {
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:            "my-channel",
+			Messages:           []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1", ID: "200"}},
+			AuditChannel:       "my-audit-channel",
+			AuditBatchInterval: "1h",
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{ID: "200", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+				}),
+				createJSONResponse(t, []discordgo.User{{ID: "user-id-999"}}),
+				createJSONResponse(t, []discordgo.User{}),
+				createEmptyResponse(t),
+				createJSONResponse(t, discordgo.Message{ID: "300"}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?after=user-id-999&limit=100"},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-999/roles/role-123"},
+				{method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-audit/messages", body: `{"embeds":[{"type":"rich","title":"Role changes (1)","description":"➕ \u003c@\u0026role-123\u003e to \u003c@user-id-999\u003e","color":3447003}],"tts":false,"components":null,"sticker_ids":null}`},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		welcomeManager.Stop()
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","audit_batch_interval":"1h0m0s","message":"discord_bot.welcome.starting_audit_batch"}`, parts[2])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.stopping_audit_batch"}`, parts[len(parts)-3])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.audit_batch_stopped"}`, parts[len(parts)-2])
+		require.Empty(t, parts[len(parts)-1])
+	})
+}
@@ -0,0 +1,169 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWelcomeManager_ErrorChannels(t *testing.T) {
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+	})
+	require.NoError(t, err)
+
+	t.Run("should return nil because message has no channel", func(t *testing.T) {
+		var bufferLogs bytes.Buffer
+
+		log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Messages: []welcome.Message{
+				{Channel: "my-channel", Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"},
+				{Title: "my title 2", Emoji: "my-emoji-1", Role: "my role 1"},
+			},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+		require.JSONEq(t, `{"level":"error","message index":1,"message":"discord_bot.welcome.configuration_empty_channel"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should return nil because channel of group is not found", func(t *testing.T) {
+		var bufferLogs bytes.Buffer
+
+		log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1", Group: "region"}},
+			Groups:   []welcome.Group{{Name: "region", Channel: "roles"}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","guild_id":"guild-123","guild":"guild-name","message":"discord_bot.welcome.set_guild_id"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","message index":0,"reaction index":0,"role_id":"role-123","role":"my role 1","message":"discord_bot.welcome.set_role_id"}`, parts[2])
+		require.JSONEq(t, `{"level":"info","message index":0,"reaction index":0,"emoji_id":"emoji-123","emoji":"my-emoji-1","message":"discord_bot.welcome.set_emoji_id"}`, parts[3])
+		require.JSONEq(t, `{"level":"error","channel":"roles","message":"discord_bot.welcome.configuration_channel_missed"}`, parts[4])
+		require.JSONEq(t, `{"level":"error","step":2,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[5])
+		require.Empty(t, parts[6])
+	})
+}
+
+//nolint:funlen
+func TestRun_MultipleChannels(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}, {ID: "channel-456", Name: "roles"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-456", Name: "my role 2"}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"},
+			{Title: "my title 2", Emoji: "✅", Role: "my role 2", Group: "platforms"},
+		},
+		Groups: []welcome.Group{{Name: "platforms", Channel: "roles"}},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.set_channel_id"}`, parts[2])
+	require.JSONEq(t, `{"level":"info","channel_id":"channel-456","channel":"roles","message":"discord_bot.welcome.set_channel_id"}`, parts[3])
+
+	t.Run("should reconcile messages channel by channel", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{ID: "200", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+				}),
+				createJSONResponse(t, []*discordgo.Message{}),
+				createJSONResponse(t, []discordgo.User{}),
+				createJSONResponse(t, discordgo.Message{ID: "300"}),
+				createEmptyResponse(t),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-456/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+				{
+					method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-456/messages",
+					body: `{"embeds":[{"type":"rich","title":"my title 2"}],"tts":false,"components":null,"sticker_ids":null}`,
+				},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/channels/channel-456/messages/300/reactions/%E2%9C%85/@me"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.fetching_messages"}`, parts[3])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-456","channel":"roles","message":"discord_bot.welcome.fetching_messages"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message_title":"my title 2","channel_id":"channel-456","channel":"roles","message":"discord_bot.welcome.adding_message"}`, parts[9])
+	})
+
+	t.Run("should route reactions by channel and message", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{createEmptyResponse(t)},
+			[]requestTest{
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-456"},
+			},
+		)
+
+		welcomeManager.OnMessageReactionAdd(nil, &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{ChannelID: "channel-123", UserID: "user-id-456", MessageID: "300", Emoji: discordgo.Emoji{Name: "✅"}},
+		})
+
+		welcomeManager.OnMessageReactionAdd(nil, &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{ChannelID: "channel-456", UserID: "user-id-456", MessageID: "300", Emoji: discordgo.Emoji{Name: "✅"}},
+		})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug","message":"discord_bot.welcome.event_message_reaction_add_received"}`, parts[0])
+		require.JSONEq(t, `{"level":"debug","message":"discord_bot.welcome.event_message_reaction_add_received"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","role_id":"role-456","role":"my role 2","channel_id":"channel-456","message_id":"300","user_id":"user-id-456","message":"discord_bot.welcome.user_role_adding"}`, parts[2])
+		require.JSONEq(t, `{"level":"info","role_id":"role-456","role":"my role 2","channel_id":"channel-456","message_id":"300","user_id":"user-id-456","message":"discord_bot.welcome.user_role_added"}`, parts[3])
+		require.Empty(t, parts[4])
+	})
+}
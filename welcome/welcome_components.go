@@ -0,0 +1,151 @@
+package welcome
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	limitButtonsPerRow      = 5
+	customIDPrefixButton    = "discord_bot.welcome.button:"
+	customIDSelectMenu      = "discord_bot.welcome.select_menu"
+	replyRoleAdded          = "Role **%s** added."
+	replyRoleRemoved        = "Role **%s** removed."
+	replyRolesUpdated       = "Your roles have been updated."
+	replyRoleUpdatingFailed = "Sorry, your roles could not be updated, please try again later."
+	signatureSeparator      = "|"
+)
+
+func (m Message) usesComponents() bool {
+	return m.Type == messageTypeButtons || m.Type == messageTypeSelectMenu
+}
+
+func (r Reaction) label() string {
+	if r.Label != "" {
+		return r.Label
+	}
+
+	return r.Role
+}
+
+func (r Reaction) componentEmoji() *discordgo.ComponentEmoji {
+	return &discordgo.ComponentEmoji{
+		Name: r.Emoji,
+		ID:   r.EmojiID,
+	}
+}
+
+func (r Reaction) buttonCustomID() string {
+	return customIDPrefixButton + r.RoleID
+}
+
+// buildComponents returns buttons or select menu of message, nil if message uses reactions.
+func (w *Manager) buildComponents(message Message) []discordgo.MessageComponent {
+	switch message.Type {
+	case messageTypeButtons:
+		return buildButtons(message)
+	case messageTypeSelectMenu:
+		return []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{w.buildSelectMenu(message)}}}
+	default:
+		return nil
+	}
+}
+
+func buildButtons(message Message) []discordgo.MessageComponent {
+	rows := []discordgo.MessageComponent{}
+
+	for reactions := range slices.Chunk(message.Reactions, limitButtonsPerRow) {
+		buttons := make([]discordgo.MessageComponent, 0, len(reactions))
+
+		for _, reaction := range reactions {
+			buttons = append(buttons, discordgo.Button{
+				Label:    reaction.label(),
+				Style:    discordgo.SecondaryButton,
+				Emoji:    reaction.componentEmoji(),
+				CustomID: reaction.buttonCustomID(),
+			})
+		}
+
+		rows = append(rows, discordgo.ActionsRow{Components: buttons})
+	}
+
+	return rows
+}
+
+func (w *Manager) buildSelectMenu(message Message) discordgo.SelectMenu {
+	minValues := 0
+	maxValues := len(message.Reactions)
+
+	if w.isExclusiveGroup(message.Group) {
+		maxValues = 1
+	}
+
+	options := make([]discordgo.SelectMenuOption, 0, len(message.Reactions))
+
+	for _, reaction := range message.Reactions {
+		options = append(options, discordgo.SelectMenuOption{
+			Label: reaction.label(),
+			Value: reaction.RoleID,
+			Emoji: reaction.componentEmoji(),
+		})
+	}
+
+	return discordgo.SelectMenu{
+		CustomID:  customIDSelectMenu,
+		MinValues: &minValues,
+		MaxValues: maxValues,
+		Options:   options,
+	}
+}
+
+// componentsSignature flattens components to compare what is published in Discord against configuration.
+func componentsSignature(components []discordgo.MessageComponent) []string {
+	signature := []string{}
+
+	for _, component := range components {
+		switch typedComponent := component.(type) {
+		case *discordgo.ActionsRow:
+			signature = append(signature, componentsSignature(typedComponent.Components)...)
+		case discordgo.ActionsRow:
+			signature = append(signature, componentsSignature(typedComponent.Components)...)
+		case *discordgo.Button:
+			signature = append(signature, buttonSignature(*typedComponent))
+		case discordgo.Button:
+			signature = append(signature, buttonSignature(typedComponent))
+		case *discordgo.SelectMenu:
+			signature = append(signature, selectMenuSignature(*typedComponent)...)
+		case discordgo.SelectMenu:
+			signature = append(signature, selectMenuSignature(typedComponent)...)
+		}
+	}
+
+	return signature
+}
+
+func buttonSignature(button discordgo.Button) string {
+	return strings.Join([]string{"button", button.CustomID, button.Label, emojiSignature(button.Emoji)}, signatureSeparator)
+}
+
+func selectMenuSignature(selectMenu discordgo.SelectMenu) []string {
+	signature := []string{strings.Join([]string{"select_menu", selectMenu.CustomID}, signatureSeparator)}
+
+	for _, option := range selectMenu.Options {
+		signature = append(signature, strings.Join([]string{"option", option.Value, option.Label, emojiSignature(option.Emoji)}, signatureSeparator))
+	}
+
+	return signature
+}
+
+func emojiSignature(emoji *discordgo.ComponentEmoji) string {
+	if emoji == nil {
+		return ""
+	}
+
+	if emoji.ID != "" {
+		return emoji.ID
+	}
+
+	return strings.ReplaceAll(emoji.Name, variationSelector, "")
+}
@@ -0,0 +1,317 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWelcomeManager_ErrorInvalidType(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:  "my-channel",
+		Messages: []welcome.Message{{Type: "radio", Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+	}, guildName, session)
+	require.Nil(t, welcomeManager)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+	require.JSONEq(t, `{"level":"error","message index":0,"type":"radio","help":"Accepted values are 'reactions', 'buttons' or 'select_menu'","message":"discord_bot.welcome.configuration_invalid_type_message"}`, parts[1])
+	require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+	require.Empty(t, parts[3])
+}
+
+//nolint:funlen
+func TestComponents_Buttons(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-456", Name: "my role 2"}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{
+				Type: "buttons", Title: "my title 1",
+				Reactions: []welcome.Reaction{{Emoji: "my-emoji-1", Role: "my role 1"}, {Emoji: "✅", Role: "my role 2", Label: "Second"}},
+			},
+		},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	t.Run("should add message with buttons and without reactions because message is not found", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{}),
+				createJSONResponse(t, discordgo.Message{ID: "123"}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{
+					method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-123/messages",
+					body: `{"embeds":[{"type":"rich","title":"my title 1"}],"tts":false,"components":[{"components":[` +
+						`{"label":"my role 1","style":2,"disabled":false,"emoji":{"name":"my-emoji-1","id":"emoji-123"},"custom_id":"discord_bot.welcome.button:role-123","type":2},` +
+						`{"label":"Second","style":2,"disabled":false,"emoji":{"name":"✅"},"custom_id":"discord_bot.welcome.button:role-456","type":2}` +
+						`],"type":1}],"sticker_ids":null}`,
+				},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.add_handler_on_message_reaction_add"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.add_handler_on_message_reaction_remove"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.add_handler_on_interaction_create"}`, parts[2])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.adding_messages"}`, parts[3])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.fetching_messages"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.messages_fetched"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message_title":"my title 1","message":"discord_bot.welcome.adding_missed_messages"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.adding_message"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","message_id":"123","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.message_added"}`, parts[8])
+		require.JSONEq(t, `{"level":"info","message_title":"my title 1","message":"discord_bot.welcome.missed_messages_added"}`, parts[9])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[10])
+		require.Empty(t, parts[11])
+	})
+
+	t.Run("should find message with same buttons and not fetch reactions", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				// components are not marshaled by discordgo, raw payload is used instead
+				createJSONResponse(t, json.RawMessage(`[`+
+					`{"id":"100","author":{"id":"bot-123"},"embeds":[{"title":"my title 1"}]},`+
+					`{"id":"123","author":{"id":"bot-123"},"embeds":[{"title":"my title 1"}],"components":[{"type":1,"components":[`+
+					`{"type":2,"label":"my role 1","style":2,"emoji":{"name":"my-emoji-1","id":"emoji-123"},"custom_id":"discord_bot.welcome.button:role-123"},`+
+					`{"type":2,"label":"Second","style":2,"emoji":{"name":"✅"},"custom_id":"discord_bot.welcome.button:role-456"}`+
+					`]}]}]`)),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.messages_fetched"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[6])
+		require.Empty(t, parts[7])
+	})
+
+	t.Run("should add role and reply ephemeral message when member clicks on button", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{createEmptyResponse(t), createEmptyResponse(t), createJSONResponse(t, discordgo.Message{})},
+			[]requestTest{
+				{
+					method: "POST", host: "discord.com", uri: "/api/v9/interactions/interaction-123/token-123/callback",
+					body: `{"type":5,"data":{"tts":false,"content":"","components":null,"embeds":null,"flags":64}}`,
+				},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-456"},
+				{
+					method: "PATCH", host: "discord.com", uri: "/api/v9/webhooks/app-123/token-123/messages/@original",
+					body: `{"content":"Role **my role 2** added."}`,
+				},
+			},
+		)
+
+		welcomeManager.OnInteractionCreate(nil, createInteractionComponent("123", []string{"role-123"}, discordgo.MessageComponentInteractionData{
+			CustomID:      "discord_bot.welcome.button:role-456",
+			ComponentType: discordgo.ButtonComponent,
+		}))
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug","message":"discord_bot.welcome.event_interaction_create_received"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","role_id":"role-456","role":"my role 2","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_adding"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","role_id":"role-456","role":"my role 2","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_added"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should remove role when member clicks on button of a role already owned", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{createEmptyResponse(t), createEmptyResponse(t), createJSONResponse(t, discordgo.Message{})},
+			[]requestTest{
+				{
+					method: "POST", host: "discord.com", uri: "/api/v9/interactions/interaction-123/token-123/callback",
+					body: `{"type":5,"data":{"tts":false,"content":"","components":null,"embeds":null,"flags":64}}`,
+				},
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-123"},
+				{
+					method: "PATCH", host: "discord.com", uri: "/api/v9/webhooks/app-123/token-123/messages/@original",
+					body: `{"content":"Role **my role 1** removed."}`,
+				},
+			},
+		)
+
+		welcomeManager.OnInteractionCreate(nil, createInteractionComponent("123", []string{"role-123"}, discordgo.MessageComponentInteractionData{
+			CustomID:      "discord_bot.welcome.button:role-123",
+			ComponentType: discordgo.ButtonComponent,
+		}))
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug","message":"discord_bot.welcome.event_interaction_create_received"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_removing"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_removed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should ignore interaction on another message", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager.OnInteractionCreate(nil, createInteractionComponent("100", []string{}, discordgo.MessageComponentInteractionData{
+			CustomID:      "discord_bot.welcome.button:role-123",
+			ComponentType: discordgo.ButtonComponent,
+		}))
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug","message":"discord_bot.welcome.event_interaction_create_received"}`, parts[0])
+		require.Empty(t, parts[1])
+	})
+}
+
+//nolint:funlen
+func TestComponents_SelectMenu(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-456", Name: "my role 2"}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{
+				Type: "select_menu", Title: "my title 1",
+				Reactions: []welcome.Reaction{{Emoji: "🇪🇺", Role: "my role 1"}, {Emoji: "✅", Role: "my role 2"}},
+			},
+		},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	t.Run("should add message with select menu", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{}),
+				createJSONResponse(t, discordgo.Message{ID: "123"}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{
+					method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-123/messages",
+					body: `{"embeds":[{"type":"rich","title":"my title 1"}],"tts":false,"components":[{"components":[` +
+						`{"custom_id":"discord_bot.welcome.select_menu","placeholder":"","min_values":0,"max_values":2,"options":[` +
+						`{"label":"my role 1","value":"role-123","description":"","emoji":{"name":"🇪🇺"},"default":false},` +
+						`{"label":"my role 2","value":"role-456","description":"","emoji":{"name":"✅"},"default":false}` +
+						`],"disabled":false,"type":3}],"type":1}],"sticker_ids":null}`,
+				},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+	})
+
+	t.Run("should add selected roles and remove unselected roles", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{createEmptyResponse(t), createEmptyResponse(t), createEmptyResponse(t), createJSONResponse(t, discordgo.Message{})},
+			[]requestTest{
+				{
+					method: "POST", host: "discord.com", uri: "/api/v9/interactions/interaction-123/token-123/callback",
+					body: `{"type":5,"data":{"tts":false,"content":"","components":null,"embeds":null,"flags":64}}`,
+				},
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-123"},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-456"},
+				{
+					method: "PATCH", host: "discord.com", uri: "/api/v9/webhooks/app-123/token-123/messages/@original",
+					body: `{"content":"Your roles have been updated."}`,
+				},
+			},
+		)
+
+		welcomeManager.OnInteractionCreate(nil, createInteractionComponent("123", []string{"role-123"}, discordgo.MessageComponentInteractionData{
+			CustomID:      "discord_bot.welcome.select_menu",
+			ComponentType: discordgo.SelectMenuComponent,
+			Values:        []string{"role-456"},
+		}))
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug","message":"discord_bot.welcome.event_interaction_create_received"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_removing"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_removed"}`, parts[2])
+		require.JSONEq(t, `{"level":"info","role_id":"role-456","role":"my role 2","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_adding"}`, parts[3])
+		require.JSONEq(t, `{"level":"info","role_id":"role-456","role":"my role 2","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_added"}`, parts[4])
+		require.Empty(t, parts[5])
+	})
+}
+
+func createInteractionComponent(messageID string, memberRoles []string, data discordgo.MessageComponentInteractionData) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:        "interaction-123",
+			AppID:     "app-123",
+			Token:     "token-123",
+			Type:      discordgo.InteractionMessageComponent,
+			ChannelID: "channel-123",
+			Message:   &discordgo.Message{ID: messageID},
+			Member:    &discordgo.Member{User: &discordgo.User{ID: "user-id-456"}, Roles: memberRoles},
+			Data:      data,
+		},
+	}
+}
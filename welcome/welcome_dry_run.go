@@ -0,0 +1,80 @@
+package welcome
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	changeRoleAdd        string = "role_add"
+	changeRoleRemove     string = "role_remove"
+	changeReactionAdd    string = "reaction_add"
+	changeReactionRemove string = "reaction_remove"
+	changeMessageAdd     string = "message_add"
+	changeMessageEdit    string = "message_edit"
+	changeMessageDelete  string = "message_delete"
+	changeDirectMessage  string = "direct_message"
+)
+
+// changes is the order of counts in the summary.
+var changes = []string{
+	changeRoleAdd,
+	changeRoleRemove,
+	changeReactionAdd,
+	changeReactionRemove,
+	changeMessageAdd,
+	changeMessageEdit,
+	changeMessageDelete,
+	changeDirectMessage,
+}
+
+// dryRunSummary counts changes skipped in dry run since the last summary.
+// Handlers and reconciliation run in their own goroutines, so counts are protected by mutex.
+type dryRunSummary struct {
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+// skipInDryRun returns true when the change must not be sent to Discord.
+// The change is logged just before by the caller with its fields.
+func (w *Manager) skipInDryRun(change string) bool {
+	if !w.dryRun {
+		return false
+	}
+
+	w.dryRunSummary.mutex.Lock()
+	defer w.dryRunSummary.mutex.Unlock()
+
+	if w.dryRunSummary.counts == nil {
+		w.dryRunSummary.counts = map[string]int{}
+	}
+
+	w.dryRunSummary.counts[change]++
+
+	log.Info().
+		Str("change", change).
+		Msg("discord_bot.welcome.dry_run_change_skipped")
+
+	return true
+}
+
+// logDryRunSummary logs counts of changes skipped and resets them.
+func (w *Manager) logDryRunSummary() {
+	if !w.dryRun {
+		return
+	}
+
+	w.dryRunSummary.mutex.Lock()
+	defer w.dryRunSummary.mutex.Unlock()
+
+	event := log.Info()
+
+	for _, change := range changes {
+		event = event.Int("count_"+change, w.dryRunSummary.counts[change])
+	}
+
+	event.Msg("discord_bot.welcome.dry_run_summary")
+
+	w.dryRunSummary.counts = nil
+}
@@ -0,0 +1,107 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:funlen
+func TestRun_DryRun(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}, {ID: "emoji-456", Name: "my-emoji-2"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-456", Name: "my role 2"}},
+		Members:  []*discordgo.Member{{User: &discordgo.User{ID: "user-id-456"}, Roles: []string{}}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"},
+			{Title: "my title 2", Emoji: "my-emoji-2", Role: "my role 2"},
+		},
+		DryRun: true,
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	t.Run("should log changes without sending them and log summary", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{ID: "200", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+				}),
+				createJSONResponse(t, []discordgo.User{{ID: "user-id-456"}}),
+				createJSONResponse(t, []discordgo.User{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?after=user-id-456&limit=100"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-456","username":"","message":"discord_bot.welcome.adding_user_role_adding"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","change":"role_add","message":"discord_bot.welcome.dry_run_change_skipped"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","message_title":"my title 2","message":"discord_bot.welcome.adding_missed_messages"}`, parts[8])
+		require.JSONEq(t, `{"level":"info","message_title":"my title 2","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.adding_message"}`, parts[9])
+		require.JSONEq(t, `{"level":"info","change":"message_add","message":"discord_bot.welcome.dry_run_change_skipped"}`, parts[10])
+		require.JSONEq(t, `{"level":"info","message_title":"my title 2","message":"discord_bot.welcome.missed_messages_added"}`, parts[11])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[12])
+		require.JSONEq(t, `{"level":"info","count_role_add":1,"count_role_remove":0,"count_reaction_add":0,"count_reaction_remove":0,"count_message_add":1,"count_message_edit":0,"count_message_delete":0,"count_direct_message":0,"message":"discord_bot.welcome.dry_run_summary"}`, parts[13])
+		require.Empty(t, parts[14])
+	})
+
+	t.Run("should not remove role on reaction remove", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t, nil, nil)
+
+		err = session.State.MemberAdd(&discordgo.Member{GuildID: "guild-123", User: &discordgo.User{ID: "user-id-456"}, Roles: []string{"role-123"}})
+		require.NoError(t, err)
+
+		welcomeManager.OnMessageReactionRemove(session, &discordgo.MessageReactionRemove{
+			MessageReaction: &discordgo.MessageReaction{
+				UserID:    "user-id-456",
+				MessageID: "200",
+				Emoji:     discordgo.Emoji{ID: "emoji-123", Name: "my-emoji-1"},
+				ChannelID: "channel-123",
+				GuildID:   "guild-123",
+			},
+		})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","channel_id":"channel-123","message_id":"200","user_id":"user-id-456","message":"discord_bot.welcome.user_role_removing"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","change":"role_remove","message":"discord_bot.welcome.dry_run_change_skipped"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+}
@@ -0,0 +1,209 @@
+package welcome
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+const botUserID = "@me"
+
+// isSameKeyAgainstConfig compares key at the end of footer when message has a key, title otherwise.
+// A message published with a key and a footer is never matched by title, other footers can change in configuration.
+func (w *Manager) isSameKeyAgainstConfig(embedFromDiscord *discordgo.MessageEmbed, messageFromConfig Message) bool {
+	footerText := embedFooterText(embedFromDiscord)
+
+	if messageFromConfig.Key != "" {
+		return footerText == messageFromConfig.Key || strings.HasSuffix(footerText, footerKeySeparator+messageFromConfig.Key)
+	}
+
+	return !w.hasKeyInFooter(footerText) && embedFromDiscord.Title == messageFromConfig.Title
+}
+
+// hasKeyInFooter checks if footer ends with a key of configuration, footers without key can contain the separator.
+func (w *Manager) hasKeyInFooter(footerText string) bool {
+	idxSeparator := strings.LastIndex(footerText, footerKeySeparator)
+	if idxSeparator == -1 {
+		return false
+	}
+
+	keyInFooter := footerText[idxSeparator+len(footerKeySeparator):]
+
+	return slices.ContainsFunc(w.messages, func(message Message) bool { return message.Key != "" && message.Key == keyInFooter })
+}
+
+// editMessage replaces embed and components of messageFromDiscord by the ones of messageFromConfig.
+// Reactions of the bot are updated to follow the configuration, reactions of users are kept.
+func (w *Manager) editMessage(messageFromDiscord *discordgo.Message, messageFromConfig Message) error {
+	log.Info().
+		Str("message_id", messageFromDiscord.ID).
+		Str("message_title", messageFromConfig.Title).
+		Str("channel_id", messageFromConfig.ChannelID).
+		Str("channel", messageFromConfig.Channel).
+		Msg("discord_bot.welcome.editing_message")
+
+	if !w.skipInDryRun(changeMessageEdit) {
+		err := w.editMessageContent(messageFromDiscord, messageFromConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	if messageFromConfig.usesComponents() {
+		if len(messageFromDiscord.Reactions) == 0 {
+			return nil
+		}
+
+		log.Info().
+			Str("message_id", messageFromDiscord.ID).
+			Msg("discord_bot.welcome.removing_all_reactions")
+
+		if w.skipInDryRun(changeReactionRemove) {
+			return nil
+		}
+
+		err := w.discordSession.MessageReactionsRemoveAll(messageFromConfig.ChannelID, messageFromDiscord.ID)
+		if err != nil {
+			log.Error().Err(err).
+				Str("message_id", messageFromDiscord.ID).
+				Msg("discord_bot.welcome.all_reactions_removing_failed")
+
+			return fmt.Errorf("%w", err)
+		}
+
+		return nil
+	}
+
+	w.removeBotReactionsNotInConfig(messageFromDiscord, messageFromConfig)
+
+	reactionsMissed := slices.DeleteFunc(slices.Clone(messageFromConfig.Reactions), func(reaction Reaction) bool {
+		return hasBotReacted(messageFromDiscord, reaction)
+	})
+
+	return w.addReactions(messageFromConfig.ChannelID, messageFromDiscord.ID, messageFromConfig.Title, reactionsMissed)
+}
+
+func (w *Manager) editMessageContent(messageFromDiscord *discordgo.Message, messageFromConfig Message) error {
+	embed := buildEmbed(messageFromConfig)
+
+	var err error
+
+	if messageFromConfig.usesComponents() || len(messageFromDiscord.Components) > 0 {
+		components := w.buildComponents(messageFromConfig)
+		if components == nil {
+			components = []discordgo.MessageComponent{}
+		}
+
+		_, err = w.discordSession.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         messageFromDiscord.ID,
+			Channel:    messageFromConfig.ChannelID,
+			Embeds:     &[]*discordgo.MessageEmbed{embed},
+			Components: &components,
+		})
+	} else {
+		_, err = w.discordSession.ChannelMessageEditEmbed(messageFromConfig.ChannelID, messageFromDiscord.ID, embed)
+	}
+
+	if err != nil {
+		log.Error().Err(err).
+			Str("message_id", messageFromDiscord.ID).
+			Str("message_title", messageFromConfig.Title).
+			Str("channel_id", messageFromConfig.ChannelID).
+			Str("channel", messageFromConfig.Channel).
+			Msg("discord_bot.welcome.message_editing_failed")
+
+		return fmt.Errorf("%w", err)
+	}
+
+	log.Info().
+		Str("message_id", messageFromDiscord.ID).
+		Str("channel_id", messageFromConfig.ChannelID).
+		Str("channel", messageFromConfig.Channel).
+		Msg("discord_bot.welcome.message_edited")
+
+	return nil
+}
+
+func hasBotReacted(messageFromDiscord *discordgo.Message, reaction Reaction) bool {
+	for _, reactionFromDiscord := range messageFromDiscord.Reactions {
+		if reactionFromDiscord.Me && reactionFromDiscord.Emoji != nil && reaction.isSameEmoji(*reactionFromDiscord.Emoji) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *Manager) removeBotReactionsNotInConfig(messageFromDiscord *discordgo.Message, messageFromConfig Message) {
+	for _, reactionFromDiscord := range messageFromDiscord.Reactions {
+		if !reactionFromDiscord.Me || reactionFromDiscord.Emoji == nil {
+			continue
+		}
+
+		isInConfig := slices.ContainsFunc(messageFromConfig.Reactions, func(reaction Reaction) bool {
+			return reaction.isSameEmoji(*reactionFromDiscord.Emoji)
+		})
+		if isInConfig {
+			continue
+		}
+
+		emoji := reactionFromDiscord.Emoji.APIName()
+
+		log.Info().
+			Str("message_id", messageFromDiscord.ID).
+			Str("emoji", emoji).
+			Msg("discord_bot.welcome.removing_bot_reaction")
+
+		if w.skipInDryRun(changeReactionRemove) {
+			continue
+		}
+
+		err := w.discordSession.MessageReactionRemove(messageFromConfig.ChannelID, messageFromDiscord.ID, emoji, botUserID)
+		if err != nil {
+			log.Error().Err(err).
+				Str("message_id", messageFromDiscord.ID).
+				Str("emoji", emoji).
+				Msg("discord_bot.welcome.bot_reaction_removing_failed")
+		}
+	}
+}
+
+// deleteMessagesNotInConfig deletes messages of the bot which are not treated.
+func (w *Manager) deleteMessagesNotInConfig(channelID string, channelName string, messagesFromDiscord []*discordgo.Message, messageIDsTreated []string) {
+	for _, messageFromDiscord := range messagesFromDiscord {
+		if slices.Contains(messageIDsTreated, messageFromDiscord.ID) {
+			continue
+		}
+
+		log.Info().
+			Str("message_id", messageFromDiscord.ID).
+			Str("message_title", messageFromDiscord.Embeds[0].Title).
+			Str("channel_id", channelID).
+			Str("channel", channelName).
+			Msg("discord_bot.welcome.deleting_unknown_message")
+
+		if w.skipInDryRun(changeMessageDelete) {
+			continue
+		}
+
+		err := w.discordSession.ChannelMessageDelete(channelID, messageFromDiscord.ID)
+		if err != nil {
+			log.Error().Err(err).
+				Str("message_id", messageFromDiscord.ID).
+				Str("channel_id", channelID).
+				Str("channel", channelName).
+				Msg("discord_bot.welcome.unknown_message_deleting_failed")
+
+			continue
+		}
+
+		log.Info().
+			Str("message_id", messageFromDiscord.ID).
+			Str("channel_id", channelID).
+			Str("channel", channelName).
+			Msg("discord_bot.welcome.unknown_message_deleted")
+	}
+}
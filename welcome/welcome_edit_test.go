@@ -0,0 +1,240 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWelcomeManager_ErrorDuplicateKey(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{Key: "rules", Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"},
+			{Key: "rules", Title: "my title 2", Emoji: "my-emoji-2", Role: "my role 2"},
+		},
+	}, guildName, session)
+	require.Nil(t, welcomeManager)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+	require.JSONEq(t, `{"level":"error","message index":1,"key":"rules","message":"discord_bot.welcome.configuration_duplicate_key_message"}`, parts[1])
+	require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+	require.Empty(t, parts[3])
+}
+
+//nolint:funlen
+func TestRun_EditMessages(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-456", Name: "my role 2"}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	t.Run("should edit message with same title and keep reactions", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Description: "fixed typo", Emoji: "my-emoji-1", Role: "my role 1"}},
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{
+						ID: "200", Author: &discordgo.User{ID: "bot-123"},
+						Embeds:    []*discordgo.MessageEmbed{{Title: "my title 1", Description: "fixed tpyo"}},
+						Reactions: []*discordgo.MessageReactions{{Count: 2, Me: true, Emoji: &discordgo.Emoji{ID: "emoji-123", Name: "my-emoji-1"}}},
+					},
+				}),
+				createJSONResponse(t, discordgo.Message{ID: "200"}),
+				createJSONResponse(t, []discordgo.User{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{
+					method: "PATCH", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200",
+					body: `{"embeds":[{"type":"rich","title":"my title 1","description":"fixed typo"}],"ID":"200","Channel":"channel-123"}`,
+				},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.messages_fetched"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.editing_message"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message_id":"200","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.message_edited"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[8])
+		require.Empty(t, parts[9])
+	})
+
+	t.Run("should edit message found by key and update reactions of bot", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Key: "rules", Title: "new title", Emoji: "✅", Role: "my role 2"}},
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{
+						ID: "200", Author: &discordgo.User{ID: "bot-123"},
+						Embeds:    []*discordgo.MessageEmbed{{Title: "old title", Footer: &discordgo.MessageEmbedFooter{Text: "rules"}}},
+						Reactions: []*discordgo.MessageReactions{{Count: 1, Me: true, Emoji: &discordgo.Emoji{ID: "emoji-123", Name: "my-emoji-1"}}},
+					},
+				}),
+				createJSONResponse(t, discordgo.Message{ID: "200"}),
+				createEmptyResponse(t),
+				createEmptyResponse(t),
+				createJSONResponse(t, []discordgo.User{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{
+					method: "PATCH", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200",
+					body: `{"embeds":[{"type":"rich","title":"new title","footer":{"text":"rules"}}],"ID":"200","Channel":"channel-123"}`,
+				},
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123/@me"},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/%E2%9C%85/@me"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/%E2%9C%85?limit=100"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"new title","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.editing_message"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message_id":"200","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.message_edited"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","message_id":"200","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.removing_bot_reaction"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"new title","emoji":"✅","message":"discord_bot.welcome.adding_reaction"}`, parts[8])
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"new title","channel_id":"channel-123","channel":"my-channel","emoji":"✅","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[9])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[10])
+		require.Empty(t, parts[11])
+	})
+
+	t.Run("should delete unknown messages of bot", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:               "my-channel",
+			Messages:              []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			DeleteUnknownMessages: true,
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{ID: "300", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "removed from configuration"}}},
+					{ID: "301", Author: &discordgo.User{ID: "user-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "not from bot"}}},
+					{ID: "200", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+				}),
+				createEmptyResponse(t),
+				createJSONResponse(t, []discordgo.User{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/300"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message_id":"300","message_title":"removed from configuration","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.deleting_unknown_message"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message_id":"300","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.unknown_message_deleted"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[8])
+		require.Empty(t, parts[9])
+	})
+
+	t.Run("should edit message without key found by title when its footer contains separator", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Description: "fixed typo", Footer: "Rules · v2", Emoji: "my-emoji-1", Role: "my role 1"}},
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{
+						ID: "200", Author: &discordgo.User{ID: "bot-123"},
+						Embeds:    []*discordgo.MessageEmbed{{Title: "my title 1", Description: "fixed tpyo", Footer: &discordgo.MessageEmbedFooter{Text: "Rules · v2"}}},
+						Reactions: []*discordgo.MessageReactions{{Count: 1, Me: true, Emoji: &discordgo.Emoji{ID: "emoji-123", Name: "my-emoji-1"}}},
+					},
+				}),
+				createJSONResponse(t, discordgo.Message{ID: "200"}),
+				createJSONResponse(t, []discordgo.User{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{
+					method: "PATCH", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200",
+					body: `{"embeds":[{"type":"rich","title":"my title 1","description":"fixed typo","footer":{"text":"Rules · v2"}}],"ID":"200","Channel":"channel-123"}`,
+				},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.editing_message"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[8])
+		require.Empty(t, parts[9])
+	})
+}
@@ -0,0 +1,188 @@
+package welcome
+
+import (
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	limitEmbedFields   int    = 25
+	footerKeySeparator string = " · "
+)
+
+// EmbedField is a struct.
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// EmbedAuthor is a struct.
+type EmbedAuthor struct {
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	IconURL string `json:"icon_url"`
+}
+
+// footerText returns Footer followed by Key, Key is kept at the end to find the message when Footer changes.
+// Key is visible by every member, messages saved in state are found by their ID before.
+func (m Message) footerText() string {
+	switch {
+	case m.Key == "":
+		return m.Footer
+	case m.Footer == "":
+		return m.Key
+	default:
+		return m.Footer + footerKeySeparator + m.Key
+	}
+}
+
+// texts returns texts of embed which accept templates and custom emojis.
+func (m *Message) texts() []*string {
+	texts := []*string{&m.Title, &m.Description, &m.Footer}
+
+	for idx := range m.Fields {
+		texts = append(texts, &m.Fields[idx].Name, &m.Fields[idx].Value)
+	}
+
+	return texts
+}
+
+// buildEmbed returns the embed of message, Key is written in footer to find the message when title changes.
+func buildEmbed(message Message) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       message.Title,
+		Description: message.Description,
+		Color:       message.Color,
+		Timestamp:   message.Timestamp,
+	}
+
+	if footerText := message.footerText(); footerText != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: footerText}
+	}
+
+	for _, field := range message.Fields {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: field.Name, Value: field.Value, Inline: field.Inline})
+	}
+
+	if message.ThumbnailURL != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: message.ThumbnailURL}
+	}
+
+	if message.ImageURL != "" {
+		embed.Image = &discordgo.MessageEmbedImage{URL: message.ImageURL}
+	}
+
+	if message.Author != nil {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: message.Author.Name, URL: message.Author.URL, IconURL: message.Author.IconURL}
+	}
+
+	return embed
+}
+
+func embedFooterText(embed *discordgo.MessageEmbed) string {
+	if embed.Footer == nil {
+		return ""
+	}
+
+	return embed.Footer.Text
+}
+
+// isSameEmbed compares what is configured in embeds, URLs of proxies and sizes of images added by Discord are ignored.
+func isSameEmbed(embedFromDiscord *discordgo.MessageEmbed, embedFromConfig *discordgo.MessageEmbed) bool {
+	return embedFromDiscord.Title == embedFromConfig.Title &&
+		embedFromDiscord.Description == embedFromConfig.Description &&
+		embedFromDiscord.Color == embedFromConfig.Color &&
+		embedFooterText(embedFromDiscord) == embedFooterText(embedFromConfig) &&
+		isSameTimestamp(embedFromDiscord.Timestamp, embedFromConfig.Timestamp) &&
+		slices.EqualFunc(embedFromDiscord.Fields, embedFromConfig.Fields, func(a *discordgo.MessageEmbedField, b *discordgo.MessageEmbedField) bool {
+			return *a == *b
+		}) &&
+		embedThumbnailURL(embedFromDiscord) == embedThumbnailURL(embedFromConfig) &&
+		embedImageURL(embedFromDiscord) == embedImageURL(embedFromConfig) &&
+		embedAuthor(embedFromDiscord) == embedAuthor(embedFromConfig)
+}
+
+// isSameTimestamp compares dates, Discord returns timestamps with another format than the configuration.
+func isSameTimestamp(timestampFromDiscord string, timestampFromConfig string) bool {
+	if timestampFromDiscord == "" || timestampFromConfig == "" {
+		return timestampFromDiscord == timestampFromConfig
+	}
+
+	dateFromDiscord, errDiscord := time.Parse(time.RFC3339, timestampFromDiscord)
+	dateFromConfig, errConfig := time.Parse(time.RFC3339, timestampFromConfig)
+
+	return errDiscord == nil && errConfig == nil && dateFromDiscord.Equal(dateFromConfig)
+}
+
+func embedThumbnailURL(embed *discordgo.MessageEmbed) string {
+	if embed.Thumbnail == nil {
+		return ""
+	}
+
+	return embed.Thumbnail.URL
+}
+
+func embedImageURL(embed *discordgo.MessageEmbed) string {
+	if embed.Image == nil {
+		return ""
+	}
+
+	return embed.Image.URL
+}
+
+func embedAuthor(embed *discordgo.MessageEmbed) EmbedAuthor {
+	if embed.Author == nil {
+		return EmbedAuthor{}
+	}
+
+	return EmbedAuthor{Name: embed.Author.Name, URL: embed.Author.URL, IconURL: embed.Author.IconURL}
+}
+
+func hasValidEmbedInFile(idxMessage int, message Message) bool {
+	if len(message.Fields) > limitEmbedFields {
+		log.Error().
+			Int("message index", idxMessage).
+			Int("limit", limitEmbedFields).
+			Msg("discord_bot.welcome.configuration_too_many_fields_message")
+
+		return false
+	}
+
+	for idxField, field := range message.Fields {
+		if field.Name == "" || field.Value == "" {
+			log.Error().
+				Int("message index", idxMessage).
+				Int("field index", idxField).
+				Msg("discord_bot.welcome.configuration_empty_field_message")
+
+			return false
+		}
+	}
+
+	if message.Author != nil && message.Author.Name == "" {
+		log.Error().
+			Int("message index", idxMessage).
+			Msg("discord_bot.welcome.configuration_empty_author_message")
+
+		return false
+	}
+
+	if message.Timestamp != "" {
+		_, err := time.Parse(time.RFC3339, message.Timestamp)
+		if err != nil {
+			log.Error().
+				Int("message index", idxMessage).
+				Str("timestamp", message.Timestamp).
+				Str("help", "Accepted values are dates like '2024-01-31T18:00:00Z'").
+				Msg("discord_bot.welcome.configuration_invalid_timestamp_message")
+
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,289 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWelcomeManager_ErrorEmbed(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	t.Run("should return nil because too many fields", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1", Fields: make([]welcome.EmbedField, 26)}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","message index":0,"limit":25,"message":"discord_bot.welcome.configuration_too_many_fields_message"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should return nil because field is empty", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1", Fields: []welcome.EmbedField{{Name: "rules", Value: "be nice"}, {Name: "faq"}}}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","message index":0,"field index":1,"message":"discord_bot.welcome.configuration_empty_field_message"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should return nil because author has no name", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1", Author: &welcome.EmbedAuthor{URL: "https://example.com"}}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","message index":0,"message":"discord_bot.welcome.configuration_empty_author_message"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should return nil because timestamp is invalid", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1", Timestamp: "31/01/2024"}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","message index":0,"timestamp":"31/01/2024","help":"Accepted values are dates like '2024-01-31T18:00:00Z'","message":"discord_bot.welcome.configuration_invalid_timestamp_message"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+}
+
+//nolint:funlen
+func TestRun_Embed(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}, {ID: "channel-456", Name: "rules"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	message := welcome.Message{
+		Key:          "welcome",
+		Title:        "my title 1",
+		Description:  "my description",
+		Fields:       []welcome.EmbedField{{Name: "Rules", Value: "read {channel:rules}", Inline: true}, {Name: "Help", Value: "ask :my-emoji-1:"}},
+		Footer:       "see you",
+		ThumbnailURL: "https://example.com/thumbnail.png",
+		ImageURL:     "https://example.com/image.png",
+		Author:       &welcome.EmbedAuthor{Name: "blueprintUE", URL: "https://blueprintue.com", IconURL: "https://example.com/icon.png"},
+		Timestamp:    "2024-01-31T18:00:00Z",
+		Emoji:        "my-emoji-1",
+		Role:         "my role 1",
+	}
+
+	t.Run("should add message with full embed", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{message},
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{}),
+				createJSONResponse(t, discordgo.Message{ID: "200"}),
+				createEmptyResponse(t),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-123/messages", body: `{"embeds":[{"type":"rich","title":"my title 1","description":"my description","timestamp":"2024-01-31T18:00:00Z","footer":{"text":"see you · welcome"},"image":{"url":"https://example.com/image.png"},"thumbnail":{"url":"https://example.com/thumbnail.png"},"author":{"url":"https://blueprintue.com","name":"blueprintUE","icon_url":"https://example.com/icon.png"},"fields":[{"name":"Rules","value":"read \u003c#channel-456\u003e","inline":true},{"name":"Help","value":"ask \u003c:my-emoji-1:emoji-123\u003e"}]}],"tts":false,"components":null,"sticker_ids":null}`},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123/@me"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+	})
+
+	t.Run("should not edit message with same embed returned by Discord", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{message},
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{
+						ID: "200", Author: &discordgo.User{ID: "bot-123"},
+						Embeds: []*discordgo.MessageEmbed{{
+							Type:        discordgo.EmbedTypeRich,
+							Title:       "my title 1",
+							Description: "my description",
+							Timestamp:   "2024-01-31T18:00:00+00:00",
+							Footer:      &discordgo.MessageEmbedFooter{Text: "see you · welcome"},
+							Image:       &discordgo.MessageEmbedImage{URL: "https://example.com/image.png", ProxyURL: "https://media.discordapp.net/image.png", Width: 640, Height: 480},
+							Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: "https://example.com/thumbnail.png", ProxyURL: "https://media.discordapp.net/thumbnail.png", Width: 64, Height: 64},
+							Author:      &discordgo.MessageEmbedAuthor{Name: "blueprintUE", URL: "https://blueprintue.com", IconURL: "https://example.com/icon.png", ProxyIconURL: "https://media.discordapp.net/icon.png"},
+							Fields: []*discordgo.MessageEmbedField{
+								{Name: "Rules", Value: "read <#channel-456>", Inline: true},
+								{Name: "Help", Value: "ask <:my-emoji-1:emoji-123>"},
+							},
+						}},
+						Reactions: []*discordgo.MessageReactions{{Count: 1, Me: true, Emoji: &discordgo.Emoji{ID: "emoji-123", Name: "my-emoji-1"}}},
+					},
+				}),
+				createJSONResponse(t, []discordgo.User{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[len(parts)-3])
+	})
+
+	t.Run("should edit message when a field changed", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Key: "welcome", Title: "my title 1", Fields: []welcome.EmbedField{{Name: "Rules", Value: "be nice"}}, Emoji: "my-emoji-1", Role: "my role 1"}},
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{
+						ID: "200", Author: &discordgo.User{ID: "bot-123"},
+						Embeds: []*discordgo.MessageEmbed{{
+							Title:  "my title 1",
+							Footer: &discordgo.MessageEmbedFooter{Text: "welcome"},
+							Fields: []*discordgo.MessageEmbedField{{Name: "Rules", Value: "be kind"}},
+						}},
+						Reactions: []*discordgo.MessageReactions{{Count: 1, Me: true, Emoji: &discordgo.Emoji{ID: "emoji-123", Name: "my-emoji-1"}}},
+					},
+				}),
+				createJSONResponse(t, discordgo.Message{ID: "200"}),
+				createJSONResponse(t, []discordgo.User{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{
+					method: "PATCH", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200",
+					body: `{"embeds":[{"type":"rich","title":"my title 1","footer":{"text":"welcome"},"fields":[{"name":"Rules","value":"be nice"}]}],"ID":"200","Channel":"channel-123"}`,
+				},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.editing_message"}`, parts[5])
+	})
+	t.Run("should edit message without key when only footer changed", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel: "my-channel",
+			Messages: []welcome.Message{
+				{Title: "my title 1", Footer: "new footer", Emoji: "my-emoji-1", Role: "my role 1"},
+				{Key: "welcome", Title: "my title 1", Footer: "see you", Emoji: "✅", Role: "my role 1"},
+			},
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{
+						ID: "300", Author: &discordgo.User{ID: "bot-123"},
+						Embeds:    []*discordgo.MessageEmbed{{Title: "my title 1", Footer: &discordgo.MessageEmbedFooter{Text: "see you · welcome"}}},
+						Reactions: []*discordgo.MessageReactions{{Count: 1, Me: true, Emoji: &discordgo.Emoji{Name: "✅"}}},
+					},
+					{
+						ID: "200", Author: &discordgo.User{ID: "bot-123"},
+						Embeds:    []*discordgo.MessageEmbed{{Title: "my title 1", Footer: &discordgo.MessageEmbedFooter{Text: "old footer"}}},
+						Reactions: []*discordgo.MessageReactions{{Count: 1, Me: true, Emoji: &discordgo.Emoji{ID: "emoji-123", Name: "my-emoji-1"}}},
+					},
+				}),
+				createJSONResponse(t, discordgo.Message{ID: "200"}),
+				createJSONResponse(t, []discordgo.User{}),
+				createJSONResponse(t, []discordgo.User{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{
+					method: "PATCH", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200",
+					body: `{"embeds":[{"type":"rich","title":"my title 1","footer":{"text":"new footer"}}],"ID":"200","Channel":"channel-123"}`,
+				},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/300/reactions/%E2%9C%85?limit=100"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.editing_message"}`, parts[5])
+	})
+}
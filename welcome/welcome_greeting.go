@@ -0,0 +1,176 @@
+package welcome
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	placeholderUserMention  string = "{user.mention}"
+	placeholderUserName     string = "{user.name}"
+	placeholderRulesChannel string = "{rules_channel}"
+)
+
+// Greeting is a struct.
+// Message is posted in Channel and DirectMessage is sent to the member when a member joins the server, both are optional.
+// Placeholders {user.mention}, {user.name}, {guild.name}, {member_count} and {rules_channel} are replaced in both texts.
+// RulesChannel is the channel of {rules_channel}, channels are names or IDs like in Configuration.
+type Greeting struct {
+	Channel        string `json:"channel"`
+	ChannelID      string `json:"channel_id"`
+	Message        string `json:"message"`
+	DirectMessage  string `json:"direct_message"`
+	RulesChannel   string `json:"rules_channel"`
+	RulesChannelID string `json:"rules_channel_id"`
+}
+
+func (g *Greeting) hasRulesChannel() bool {
+	return g.RulesChannel != "" || g.RulesChannelID != ""
+}
+
+func hasValidGreetingInFile(greeting *Greeting) bool {
+	if greeting == nil {
+		return true
+	}
+
+	if greeting.Message == "" && greeting.DirectMessage == "" {
+		log.Error().
+			Msg("discord_bot.welcome.configuration_empty_greeting")
+
+		return false
+	}
+
+	if greeting.Message != "" && greeting.Channel == "" && greeting.ChannelID == "" {
+		log.Error().
+			Msg("discord_bot.welcome.configuration_empty_greeting_channel")
+
+		return false
+	}
+
+	usesRulesChannel := strings.Contains(greeting.Message, placeholderRulesChannel) || strings.Contains(greeting.DirectMessage, placeholderRulesChannel)
+	if usesRulesChannel && !greeting.hasRulesChannel() {
+		log.Error().
+			Str("help", "Set rules_channel to use {rules_channel}").
+			Msg("discord_bot.welcome.configuration_empty_greeting_rules_channel")
+
+		return false
+	}
+
+	return true
+}
+
+// resolveChannels sets IDs and names of channels of greeting found in guild.
+func (g *Greeting) resolveChannels(guild *discordgo.Guild) {
+	if g.Channel != "" || g.ChannelID != "" {
+		channels := findChannels(guild, g.ChannelID, g.Channel)
+		if len(channels) == 1 {
+			log.Info().
+				Str("channel_id", channels[0].ID).
+				Str("channel", channels[0].Name).
+				Msg("discord_bot.welcome.set_greeting_channel_id")
+
+			g.ChannelID = channels[0].ID
+			g.Channel = channels[0].Name
+		}
+	}
+
+	if g.hasRulesChannel() {
+		channels := findChannels(guild, g.RulesChannelID, g.RulesChannel)
+		if len(channels) == 1 {
+			log.Info().
+				Str("channel_id", channels[0].ID).
+				Str("channel", channels[0].Name).
+				Msg("discord_bot.welcome.set_greeting_rules_channel_id")
+
+			g.RulesChannelID = channels[0].ID
+			g.RulesChannel = channels[0].Name
+		}
+	}
+}
+
+func (g *Greeting) hasValidChannelsAgainstDiscordServer(guild *discordgo.Guild) bool {
+	if (g.Channel != "" || g.ChannelID != "") && !hasValidChannelAgainstDiscordServer(guild, g.ChannelID, g.Channel) {
+		return false
+	}
+
+	if g.hasRulesChannel() && !hasValidChannelAgainstDiscordServer(guild, g.RulesChannelID, g.RulesChannel) {
+		return false
+	}
+
+	return true
+}
+
+// OnGuildMemberAdd is public for tests, never call it directly
+func (w *Manager) OnGuildMemberAdd(_ *discordgo.Session, member *discordgo.GuildMemberAdd) {
+	log.Debug().
+		Msg("discord_bot.welcome.event_guild_member_add_received")
+
+	if member == nil || member.Member == nil || member.User == nil || member.GuildID != w.guildID || member.User.Bot {
+		return
+	}
+
+	if w.greeting.Message != "" {
+		w.sendGreeting(member.Member)
+	}
+
+	if w.greeting.DirectMessage != "" {
+		w.sendDirectMessage(member.User.ID, w.greetingContent(w.greeting.DirectMessage, member.Member))
+	}
+}
+
+func (w *Manager) sendGreeting(member *discordgo.Member) {
+	log.Info().
+		Str("channel_id", w.greeting.ChannelID).
+		Str("channel", w.greeting.Channel).
+		Str("user_id", member.User.ID).
+		Msg("discord_bot.welcome.sending_greeting")
+
+	if w.skipInDryRun(changeMessageAdd) {
+		return
+	}
+
+	_, err := w.discordSession.ChannelMessageSend(w.greeting.ChannelID, w.greetingContent(w.greeting.Message, member))
+	if err != nil {
+		log.Error().Err(err).
+			Str("channel_id", w.greeting.ChannelID).
+			Str("channel", w.greeting.Channel).
+			Str("user_id", member.User.ID).
+			Msg("discord_bot.welcome.greeting_sending_failed")
+
+		return
+	}
+
+	log.Info().
+		Str("channel_id", w.greeting.ChannelID).
+		Str("channel", w.greeting.Channel).
+		Str("user_id", member.User.ID).
+		Msg("discord_bot.welcome.greeting_sent")
+}
+
+// greetingContent replaces placeholders of text, member count comes from state which counts the member who just joined.
+func (w *Manager) greetingContent(text string, member *discordgo.Member) string {
+	guildName := w.guildName
+	memberCount := 0
+
+	guild, err := w.discordSession.State.Guild(w.guildID)
+	if err == nil {
+		guildName = guild.Name
+		memberCount = guild.MemberCount
+	}
+
+	rulesChannel := ""
+	if w.greeting.RulesChannelID != "" {
+		rulesChannel = "<#" + w.greeting.RulesChannelID + ">"
+	}
+
+	return strings.NewReplacer(
+		placeholderUserMention, member.User.Mention(),
+		placeholderUserName, member.User.DisplayName(),
+		placeholderGuildName, guildName,
+		placeholderMemberCount, strconv.Itoa(memberCount),
+		placeholderRulesChannel, rulesChannel,
+	).Replace(text)
+}
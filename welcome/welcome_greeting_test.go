@@ -0,0 +1,147 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWelcomeManager_ErrorGreeting(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	t.Run("should return nil because greeting has no message", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			Greeting: &welcome.Greeting{Channel: "my-channel"},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","message":"discord_bot.welcome.configuration_empty_greeting"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should return nil because greeting message has no channel", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			Greeting: &welcome.Greeting{Message: "Welcome {user.mention}"},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","message":"discord_bot.welcome.configuration_empty_greeting_channel"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should return nil because rules channel is used but not set", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			Greeting: &welcome.Greeting{DirectMessage: "Read {rules_channel}"},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","help":"Set rules_channel to use {rules_channel}","message":"discord_bot.welcome.configuration_empty_greeting_rules_channel"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+}
+
+//nolint:funlen
+func TestHandlers_OnGuildMemberAdd(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:          "guild-123",
+		Name:        guildName,
+		MemberCount: 42,
+		Channels:    []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}, {ID: "channel-456", Name: "welcome"}, {ID: "channel-789", Name: "rules"}},
+		Emojis:      []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:       []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
+		Members:     []*discordgo.Member{{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:  "my-channel",
+		Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1", ID: "123"}},
+		Greeting: &welcome.Greeting{
+			Channel:       "welcome",
+			Message:       "Welcome {user.mention} to {guild.name}, you are the member #{member_count} :my-emoji-1:",
+			DirectMessage: "Hello {user.name}, please read {rules_channel}",
+			RulesChannel:  "rules",
+		},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	t.Run("should do nothing because member is a bot", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager.OnGuildMemberAdd(nil, &discordgo.GuildMemberAdd{Member: &discordgo.Member{GuildID: "guild-123", User: &discordgo.User{ID: "user-id-999", Bot: true}}})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug","message":"discord_bot.welcome.event_guild_member_add_received"}`, parts[0])
+		require.Empty(t, parts[1])
+	})
+
+	t.Run("should greet member in channel and by direct message", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, discordgo.Message{ID: "300"}),
+				createJSONResponse(t, discordgo.Channel{ID: "dm-456"}),
+				createJSONResponse(t, discordgo.Message{ID: "301"}),
+			},
+			[]requestTest{
+				{method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-456/messages", body: `{"content":"Welcome \u003c@user-id-456\u003e to ` + guildName + `, you are the member #42 \u003c:my-emoji-1:emoji-123\u003e","embeds":null,"tts":false,"components":null,"sticker_ids":null}`},
+				{method: "POST", host: "discord.com", uri: "/api/v9/users/@me/channels", body: `{"recipient_id":"user-id-456"}`},
+				{method: "POST", host: "discord.com", uri: "/api/v9/channels/dm-456/messages", body: `{"content":"Hello user 456, please read \u003c#channel-789\u003e","embeds":null,"tts":false,"components":null,"sticker_ids":null}`},
+			},
+		)
+
+		welcomeManager.OnGuildMemberAdd(nil, &discordgo.GuildMemberAdd{Member: &discordgo.Member{GuildID: "guild-123", User: &discordgo.User{ID: "user-id-456", Username: "user 456"}}})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug","message":"discord_bot.welcome.event_guild_member_add_received"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-456","channel":"welcome","user_id":"user-id-456","message":"discord_bot.welcome.sending_greeting"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-456","channel":"welcome","user_id":"user-id-456","message":"discord_bot.welcome.greeting_sent"}`, parts[2])
+		require.JSONEq(t, `{"level":"info","user_id":"user-id-456","message":"discord_bot.welcome.sending_direct_message"}`, parts[3])
+		require.JSONEq(t, `{"level":"info","user_id":"user-id-456","message":"discord_bot.welcome.direct_message_sent"}`, parts[4])
+		require.Empty(t, parts[5])
+	})
+}
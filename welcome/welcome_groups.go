@@ -0,0 +1,153 @@
+package welcome
+
+import (
+	"slices"
+
+	"github.com/rs/zerolog/log"
+)
+
+// exclusiveChoices keeps for each exclusive group the reaction kept by each user: group name -> user ID -> reaction.
+type exclusiveChoices map[string]map[string]Reaction
+
+func (w *Manager) isExclusiveGroup(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, group := range w.groups {
+		if group.Name == name {
+			return group.Exclusive
+		}
+	}
+
+	return false
+}
+
+// chooseReactionInExclusiveGroup returns false when user has already chosen another reaction in the exclusive group of the message.
+// In that case the reaction of the user is removed.
+func (w *Manager) chooseReactionInExclusiveGroup(choices exclusiveChoices, message Message, reaction Reaction, userID string) bool {
+	if !w.isExclusiveGroup(message.Group) {
+		return true
+	}
+
+	if choices[message.Group] == nil {
+		choices[message.Group] = map[string]Reaction{}
+	}
+
+	reactionChosen, found := choices[message.Group][userID]
+	if !found {
+		choices[message.Group][userID] = reaction
+
+		return true
+	}
+
+	if reactionChosen.RoleID == reaction.RoleID {
+		return true
+	}
+
+	w.removeReactionInExclusiveGroup(message, reaction, userID)
+
+	return false
+}
+
+func (w *Manager) removeRolesNotChosenInExclusiveGroups(choices exclusiveChoices) {
+	for group, users := range choices {
+		for userID, reactionChosen := range users {
+			w.removeOtherRolesInExclusiveGroup(group, reactionChosen, userID, false)
+		}
+	}
+}
+
+// removeOtherRolesInExclusiveGroup removes from user the roles of the group except the role of reactionKept.
+// If withReactions is true, reactions of the user on these roles are removed too.
+func (w *Manager) removeOtherRolesInExclusiveGroup(group string, reactionKept Reaction, userID string, withReactions bool) {
+	member, err := w.discordSession.State.Member(w.guildID, userID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("group", group).
+			Str("user_id", userID).
+			Msg("discord_bot.welcome.exclusive_group_member_fetching_failed")
+
+		return
+	}
+
+	for _, message := range w.messages {
+		if message.Group != group {
+			continue
+		}
+
+		for _, reaction := range message.Reactions {
+			if reaction.RoleID == reactionKept.RoleID || !slices.Contains(member.Roles, reaction.RoleID) {
+				continue
+			}
+
+			log.Info().
+				Str("group", group).
+				Str("role_id", reaction.RoleID).
+				Str("role", reaction.Role).
+				Str("user_id", userID).
+				Msg("discord_bot.welcome.exclusive_user_role_removing")
+
+			if w.skipInDryRun(changeRoleRemove) {
+				continue
+			}
+
+			err = w.discordSession.GuildMemberRoleRemove(w.guildID, userID, reaction.RoleID)
+			if err != nil {
+				log.Error().Err(err).
+					Str("group", group).
+					Str("role_id", reaction.RoleID).
+					Str("role", reaction.Role).
+					Str("user_id", userID).
+					Msg("discord_bot.welcome.exclusive_user_role_removing_failed")
+
+				w.audit(auditRoleRemovingFailed, userID, reaction.RoleID, err)
+
+				continue
+			}
+
+			w.setMemberRoleInState(userID, reaction.RoleID, false)
+
+			w.audit(auditRoleRemoved, userID, reaction.RoleID, nil)
+
+			log.Info().
+				Str("group", group).
+				Str("role_id", reaction.RoleID).
+				Str("role", reaction.Role).
+				Str("user_id", userID).
+				Msg("discord_bot.welcome.exclusive_user_role_removed")
+
+			// reaction is removed after the role, its event is then ignored
+			if withReactions {
+				w.removeReactionInExclusiveGroup(message, reaction, userID)
+			}
+		}
+	}
+}
+
+func (w *Manager) removeReactionInExclusiveGroup(message Message, reaction Reaction, userID string) {
+	if message.ID == "" || message.usesComponents() {
+		return
+	}
+
+	log.Info().
+		Str("group", message.Group).
+		Str("message_id", message.ID).
+		Str("emoji", reaction.emojiAPIName()).
+		Str("user_id", userID).
+		Msg("discord_bot.welcome.exclusive_reaction_removing")
+
+	if w.skipInDryRun(changeReactionRemove) {
+		return
+	}
+
+	err := w.discordSession.MessageReactionRemove(message.ChannelID, message.ID, reaction.emojiAPIName(), userID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("group", message.Group).
+			Str("message_id", message.ID).
+			Str("emoji", reaction.emojiAPIName()).
+			Str("user_id", userID).
+			Msg("discord_bot.welcome.exclusive_reaction_removing_failed")
+	}
+}
@@ -0,0 +1,188 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWelcomeManager_ErrorGroups(t *testing.T) {
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	t.Run("should return nil because group name is empty", func(t *testing.T) {
+		var bufferLogs bytes.Buffer
+
+		log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			Groups:   []welcome.Group{{Exclusive: true}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+		require.JSONEq(t, `{"level":"error","group index":0,"message":"discord_bot.welcome.configuration_empty_group_name"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should return nil because group is defined twice", func(t *testing.T) {
+		var bufferLogs bytes.Buffer
+
+		log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			Groups:   []welcome.Group{{Name: "region"}, {Name: "region", Exclusive: true}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+		require.JSONEq(t, `{"level":"error","group index":1,"group":"region","message":"discord_bot.welcome.configuration_duplicate_group"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should return nil because message group is not defined", func(t *testing.T) {
+		var bufferLogs bytes.Buffer
+
+		log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1", Group: "region"}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+		require.JSONEq(t, `{"level":"error","message index":0,"group":"region","message":"discord_bot.welcome.configuration_unknown_group_message"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+}
+
+//nolint:funlen
+func TestExclusiveGroup(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "EU"}, {ID: "role-456", Name: "NA"}},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "user-id-456"}, Roles: []string{"role-456"}},
+			{User: &discordgo.User{ID: "user-id-789"}, Roles: []string{"role-123"}},
+		},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{Title: "Europe", Emoji: "my-emoji-1", Role: "EU", Group: "region"},
+			{Title: "North America", Emoji: "✅", Role: "NA", Group: "region"},
+		},
+		Groups: []welcome.Group{{Name: "region", Exclusive: true}},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	t.Run("should keep only the first reaction in configuration order on startup", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{ID: "201", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "North America"}}},
+					{ID: "200", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "Europe"}}},
+				}),
+				createJSONResponse(t, []discordgo.User{{ID: "user-id-456", Username: "user lambda 456"}}),
+				createJSONResponse(t, []discordgo.User{}),
+				createEmptyResponse(t),
+				createJSONResponse(t, []discordgo.User{{ID: "user-id-456", Username: "user lambda 456"}}),
+				createJSONResponse(t, []discordgo.User{}),
+				createEmptyResponse(t),
+				createEmptyResponse(t),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?after=user-id-456&limit=100"},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-123"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/201/reactions/%E2%9C%85?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/201/reactions/%E2%9C%85?after=user-id-456&limit=100"},
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/201/reactions/%E2%9C%85/user-id-456"},
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-456"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"Europe","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"EU","user_id":"user-id-456","username":"user lambda 456","message":"discord_bot.welcome.adding_user_role_adding"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","message_id":"201","message_title":"North America","channel_id":"channel-123","channel":"my-channel","emoji":"✅","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","group":"region","message_id":"201","emoji":"✅","user_id":"user-id-456","message":"discord_bot.welcome.exclusive_reaction_removing"}`, parts[8])
+		require.JSONEq(t, `{"level":"info","group":"region","role_id":"role-456","role":"NA","user_id":"user-id-456","message":"discord_bot.welcome.exclusive_user_role_removing"}`, parts[9])
+		require.JSONEq(t, `{"level":"info","group":"region","role_id":"role-456","role":"NA","user_id":"user-id-456","message":"discord_bot.welcome.exclusive_user_role_removed"}`, parts[10])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[11])
+		require.Empty(t, parts[12])
+	})
+
+	t.Run("should remove other role and reaction of the group when user reacts", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{createEmptyResponse(t), createEmptyResponse(t), createEmptyResponse(t)},
+			[]requestTest{
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-789/roles/role-456"},
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-789/roles/role-123"},
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123/user-id-789"},
+			},
+		)
+
+		welcomeManager.OnMessageReactionAdd(nil, &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{
+				ChannelID: "channel-123",
+				UserID:    "user-id-789",
+				MessageID: "201",
+				Emoji:     discordgo.Emoji{Name: "✅"},
+			},
+		})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug", "message":"discord_bot.welcome.event_message_reaction_add_received"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","role_id":"role-456","role":"NA","channel_id":"channel-123","message_id":"201","user_id":"user-id-789","message":"discord_bot.welcome.user_role_adding"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","role_id":"role-456","role":"NA","channel_id":"channel-123","message_id":"201","user_id":"user-id-789","message":"discord_bot.welcome.user_role_added"}`, parts[2])
+		require.JSONEq(t, `{"level":"info","group":"region","role_id":"role-123","role":"EU","user_id":"user-id-789","message":"discord_bot.welcome.exclusive_user_role_removing"}`, parts[3])
+		require.JSONEq(t, `{"level":"info","group":"region","role_id":"role-123","role":"EU","user_id":"user-id-789","message":"discord_bot.welcome.exclusive_user_role_removed"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","group":"region","message_id":"200","emoji":"my-emoji-1:emoji-123","user_id":"user-id-789","message":"discord_bot.welcome.exclusive_reaction_removing"}`, parts[5])
+		require.Empty(t, parts[6])
+	})
+}
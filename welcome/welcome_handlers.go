@@ -1,13 +1,15 @@
 package welcome
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/bwmarrin/discordgo"
 	"github.com/rs/zerolog/log"
 )
 
 // OnMessageReactionAdd is public for tests, never call it directly
-//
-//nolint:dupl
 func (w *Manager) OnMessageReactionAdd(_ *discordgo.Session, reaction *discordgo.MessageReactionAdd) {
 	log.Debug().
 		Msg("discord_bot.welcome.event_message_reaction_add_received")
@@ -16,44 +18,33 @@ func (w *Manager) OnMessageReactionAdd(_ *discordgo.Session, reaction *discordgo
 		return
 	}
 
-	idxMessageFound, found := w.isMessageReactionMatching(reaction.MessageReaction)
+	messageFound, reactionFound, found := w.isMessageReactionMatching(reaction.MessageReaction)
 	if !found {
 		return
 	}
 
-	log.Info().
-		Str("role_id", w.messages[idxMessageFound].RoleID).
-		Str("role", w.messages[idxMessageFound].Role).
-		Str("channel_id", reaction.ChannelID).
-		Str("message_id", reaction.MessageID).
-		Str("user_id", reaction.UserID).
-		Msg("discord_bot.welcome.user_role_adding")
-
-	err := w.discordSession.GuildMemberRoleAdd(w.guildID, reaction.UserID, w.messages[idxMessageFound].RoleID)
-	if err != nil {
-		log.Error().Err(err).
-			Str("role_id", w.messages[idxMessageFound].RoleID).
-			Str("role", w.messages[idxMessageFound].Role).
-			Str("channel_id", reaction.ChannelID).
-			Str("message_id", reaction.MessageID).
-			Str("user_id", reaction.UserID).
-			Msg("discord_bot.welcome.user_role_adding_failed")
+	refusal := w.ageGateRefusal(messageFound, reactionFound, reaction.UserID, reaction.Member)
+	if refusal != "" {
+		w.refuseReaction(messageFound, reactionFound, reaction.UserID, refusal, messageFound.DirectMessageAgeGate)
 
 		return
 	}
 
-	log.Info().
-		Str("role_id", w.messages[idxMessageFound].RoleID).
-		Str("role", w.messages[idxMessageFound].Role).
-		Str("channel_id", reaction.ChannelID).
-		Str("message_id", reaction.MessageID).
-		Str("user_id", reaction.UserID).
-		Msg("discord_bot.welcome.user_role_added")
+	if messageFound.CanPurgeReactions {
+		w.reactionsOfMembers.add(reaction.UserID, reaction.MessageID, reactionFound.emojiAPIName())
+	}
+
+	w.changeUserRole(roleChange{
+		add:       true,
+		channelID: reaction.ChannelID,
+		messageID: reaction.MessageID,
+		userID:    reaction.UserID,
+		reaction:  reactionFound,
+		group:     messageFound.Group,
+	})
 }
 
 // OnMessageReactionRemove is public for tests, never call it directly
-//
-//nolint:dupl
 func (w *Manager) OnMessageReactionRemove(_ *discordgo.Session, reaction *discordgo.MessageReactionRemove) {
 	log.Debug().
 		Msg("discord_bot.welcome.event_message_reaction_remove_received")
@@ -62,67 +53,394 @@ func (w *Manager) OnMessageReactionRemove(_ *discordgo.Session, reaction *discor
 		return
 	}
 
-	idxMessageFound, found := w.isMessageReactionMatching(reaction.MessageReaction)
+	messageFound, reactionFound, found := w.isMessageReactionMatching(reaction.MessageReaction)
+	if !found {
+		return
+	}
+
+	if messageFound.CanPurgeReactions {
+		w.reactionsOfMembers.remove(reaction.UserID, reaction.MessageID, reactionFound.emojiAPIName())
+	}
+
+	// reactions removed by the bot for refusals, exclusive groups and departed members come back here
+	member, err := w.discordSession.State.Member(w.guildID, reaction.UserID)
+	if err != nil || !slices.Contains(member.Roles, reactionFound.RoleID) {
+		log.Info().
+			Str("role_id", reactionFound.RoleID).
+			Str("role", reactionFound.Role).
+			Str("user_id", reaction.UserID).
+			Bool("is_member", err == nil).
+			Msg("discord_bot.welcome.user_role_removing_skipped")
+
+		// an add of the role still queued or waiting for a retry must not give the role back
+		w.cancelRoleChanges(reaction.UserID, reactionFound.RoleID)
+
+		return
+	}
+
+	w.changeUserRole(roleChange{
+		add:       false,
+		channelID: reaction.ChannelID,
+		messageID: reaction.MessageID,
+		userID:    reaction.UserID,
+		reaction:  reactionFound,
+	})
+}
+
+// OnGuildMemberRemove is public for tests, never call it directly
+// Reactions of the member who left are removed from messages with CanPurgeReactions, the startup purge catches up members who left while offline.
+// Only reactions seen at startup or by handlers are removed, their events do not remove roles because the member is gone.
+func (w *Manager) OnGuildMemberRemove(_ *discordgo.Session, member *discordgo.GuildMemberRemove) {
+	log.Debug().
+		Msg("discord_bot.welcome.event_guild_member_remove_received")
+
+	if member == nil || member.Member == nil || member.User == nil || member.GuildID != w.guildID {
+		return
+	}
+
+	if w.isUserBot(member.User.ID) {
+		return
+	}
+
+	reactionsOfMember := w.reactionsOfMembers.forget(member.User.ID)
+	if len(reactionsOfMember) == 0 {
+		return
+	}
+
+	for _, message := range w.messages {
+		if !message.CanPurgeReactions || message.ID == "" || message.usesComponents() {
+			continue
+		}
+
+		for _, reaction := range message.Reactions {
+			_, hasReacted := reactionsOfMember[reactionOfMember{messageID: message.ID, emoji: reaction.emojiAPIName()}]
+			if !hasReacted {
+				continue
+			}
+
+			log.Info().
+				Str("message_id", message.ID).
+				Str("emoji", reaction.emojiAPIName()).
+				Str("user_id", member.User.ID).
+				Msg("discord_bot.welcome.departed_member_reaction_removing")
+
+			if w.skipInDryRun(changeReactionRemove) {
+				continue
+			}
+
+			err := w.discordSession.MessageReactionRemove(message.ChannelID, message.ID, reaction.emojiAPIName(), member.User.ID)
+			if err != nil {
+				log.Error().Err(err).
+					Str("message_id", message.ID).
+					Str("emoji", reaction.emojiAPIName()).
+					Str("user_id", member.User.ID).
+					Msg("discord_bot.welcome.departed_member_reaction_removing_failed")
+			}
+		}
+	}
+}
+
+// OnInteractionCreate is public for tests, never call it directly
+func (w *Manager) OnInteractionCreate(_ *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	log.Debug().
+		Msg("discord_bot.welcome.event_interaction_create_received")
+
+	if interaction == nil || interaction.Interaction == nil || interaction.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+
+	if interaction.Message == nil || interaction.Member == nil || interaction.Member.User == nil {
+		return
+	}
+
+	messageFound, found := w.isMessageComponentMatching(interaction.ChannelID, interaction.Message.ID)
 	if !found {
 		return
 	}
 
+	data := interaction.MessageComponentData()
+
+	var reactionFound Reaction
+
+	if messageFound.Type == messageTypeButtons {
+		idxReaction := slices.IndexFunc(messageFound.Reactions, func(reaction Reaction) bool { return reaction.buttonCustomID() == data.CustomID })
+		if idxReaction == -1 {
+			return
+		}
+
+		reactionFound = messageFound.Reactions[idxReaction]
+	}
+
+	// Discord waits 3 seconds for a response, roles updates and audit can take longer with rate limits
+	err := w.discordSession.InteractionRespond(interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Error().Err(err).
+			Str("channel_id", interaction.ChannelID).
+			Str("message_id", interaction.Message.ID).
+			Str("user_id", interaction.Member.User.ID).
+			Msg("discord_bot.welcome.interaction_responding_failed")
+	}
+
+	var reply string
+
+	switch messageFound.Type {
+	case messageTypeButtons:
+		reply = w.toggleUserRoleFromButton(interaction.ChannelID, messageFound, interaction.Member, reactionFound)
+	case messageTypeSelectMenu:
+		reply = w.updateUserRolesFromSelectMenu(interaction.ChannelID, messageFound, interaction.Member, data.Values)
+	}
+
+	if err != nil {
+		return
+	}
+
+	_, err = w.discordSession.InteractionResponseEdit(interaction.Interaction, &discordgo.WebhookEdit{
+		Content: &reply,
+	})
+	if err != nil {
+		log.Error().Err(err).
+			Str("channel_id", interaction.ChannelID).
+			Str("message_id", interaction.Message.ID).
+			Str("user_id", interaction.Member.User.ID).
+			Msg("discord_bot.welcome.interaction_response_editing_failed")
+	}
+}
+
+func (w *Manager) toggleUserRoleFromButton(channelID string, message Message, member *discordgo.Member, reaction Reaction) string {
+	if slices.Contains(member.Roles, reaction.RoleID) {
+		err := w.removeUserRole(channelID, message.ID, member.User.ID, reaction)
+		if err != nil {
+			return replyRoleUpdatingFailed
+		}
+
+		return fmt.Sprintf(replyRoleRemoved, reaction.Role)
+	}
+
+	refusal := w.ageGateRefusal(message, reaction, member.User.ID, member)
+	if refusal != "" {
+		return refusal
+	}
+
+	err := w.addUserRole(channelID, message.ID, member.User.ID, reaction)
+	if err != nil {
+		return replyRoleUpdatingFailed
+	}
+
+	if w.isExclusiveGroup(message.Group) {
+		w.removeOtherRolesInExclusiveGroup(message.Group, reaction, member.User.ID, true)
+	}
+
+	return fmt.Sprintf(replyRoleAdded, reaction.Role)
+}
+
+// updateUserRolesFromSelectMenu gives roles selected by the member and removes roles of the select menu not selected.
+func (w *Manager) updateUserRolesFromSelectMenu(channelID string, message Message, member *discordgo.Member, values []string) string {
+	hasFailed := false
+	refusals := []string{}
+
+	for _, reaction := range message.Reactions {
+		isSelected := slices.Contains(values, reaction.RoleID)
+		hasRole := slices.Contains(member.Roles, reaction.RoleID)
+
+		switch {
+		case isSelected && !hasRole:
+			refusal := w.ageGateRefusal(message, reaction, member.User.ID, member)
+			if refusal != "" {
+				refusals = append(refusals, refusal)
+
+				continue
+			}
+
+			err := w.addUserRole(channelID, message.ID, member.User.ID, reaction)
+			if err != nil {
+				hasFailed = true
+
+				continue
+			}
+
+			if w.isExclusiveGroup(message.Group) {
+				w.removeOtherRolesInExclusiveGroup(message.Group, reaction, member.User.ID, true)
+			}
+		case !isSelected && hasRole:
+			err := w.removeUserRole(channelID, message.ID, member.User.ID, reaction)
+			if err != nil {
+				hasFailed = true
+			}
+		}
+	}
+
+	if hasFailed {
+		return replyRoleUpdatingFailed
+	}
+
+	if len(refusals) > 0 {
+		return strings.Join(refusals, "\n")
+	}
+
+	return replyRolesUpdated
+}
+
+func (w *Manager) addUserRole(channelID string, messageID string, userID string, reaction Reaction) error {
+	err := w.requestUserRoleAdd(channelID, messageID, userID, reaction)
+	if err != nil {
+		w.audit(auditRoleAddingFailed, userID, reaction.RoleID, err)
+	}
+
+	return err
+}
+
+func (w *Manager) removeUserRole(channelID string, messageID string, userID string, reaction Reaction) error {
+	err := w.requestUserRoleRemove(channelID, messageID, userID, reaction)
+	if err != nil {
+		w.audit(auditRoleRemovingFailed, userID, reaction.RoleID, err)
+	}
+
+	return err
+}
+
+// requestUserRoleAdd adds the role and audits it, a failure is audited by the caller which may retry.
+func (w *Manager) requestUserRoleAdd(channelID string, messageID string, userID string, reaction Reaction) error {
+	log.Info().
+		Str("role_id", reaction.RoleID).
+		Str("role", reaction.Role).
+		Str("channel_id", channelID).
+		Str("message_id", messageID).
+		Str("user_id", userID).
+		Msg("discord_bot.welcome.user_role_adding")
+
+	if w.skipInDryRun(changeRoleAdd) {
+		return nil
+	}
+
+	err := w.discordSession.GuildMemberRoleAdd(w.guildID, userID, reaction.RoleID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("role_id", reaction.RoleID).
+			Str("role", reaction.Role).
+			Str("channel_id", channelID).
+			Str("message_id", messageID).
+			Str("user_id", userID).
+			Msg("discord_bot.welcome.user_role_adding_failed")
+
+		return fmt.Errorf("%w", err)
+	}
+
+	w.setMemberRoleInState(userID, reaction.RoleID, true)
+
+	w.audit(auditRoleAdded, userID, reaction.RoleID, nil)
+
 	log.Info().
-		Str("role_id", w.messages[idxMessageFound].RoleID).
-		Str("role", w.messages[idxMessageFound].Role).
-		Str("channel_id", reaction.ChannelID).
-		Str("message_id", reaction.MessageID).
-		Str("user_id", reaction.UserID).
+		Str("role_id", reaction.RoleID).
+		Str("role", reaction.Role).
+		Str("channel_id", channelID).
+		Str("message_id", messageID).
+		Str("user_id", userID).
+		Msg("discord_bot.welcome.user_role_added")
+
+	return nil
+}
+
+// requestUserRoleRemove removes the role and audits it, a failure is audited by the caller which may retry.
+func (w *Manager) requestUserRoleRemove(channelID string, messageID string, userID string, reaction Reaction) error {
+	log.Info().
+		Str("role_id", reaction.RoleID).
+		Str("role", reaction.Role).
+		Str("channel_id", channelID).
+		Str("message_id", messageID).
+		Str("user_id", userID).
 		Msg("discord_bot.welcome.user_role_removing")
 
-	err := w.discordSession.GuildMemberRoleRemove(w.guildID, reaction.UserID, w.messages[idxMessageFound].RoleID)
+	if w.skipInDryRun(changeRoleRemove) {
+		return nil
+	}
+
+	err := w.discordSession.GuildMemberRoleRemove(w.guildID, userID, reaction.RoleID)
 	if err != nil {
 		log.Error().Err(err).
-			Str("role_id", w.messages[idxMessageFound].RoleID).
-			Str("role", w.messages[idxMessageFound].Role).
-			Str("channel_id", reaction.ChannelID).
-			Str("message_id", reaction.MessageID).
-			Str("user_id", reaction.UserID).
+			Str("role_id", reaction.RoleID).
+			Str("role", reaction.Role).
+			Str("channel_id", channelID).
+			Str("message_id", messageID).
+			Str("user_id", userID).
 			Msg("discord_bot.welcome.user_role_removing_failed")
 
-		return
+		return fmt.Errorf("%w", err)
 	}
 
+	w.setMemberRoleInState(userID, reaction.RoleID, false)
+
+	w.audit(auditRoleRemoved, userID, reaction.RoleID, nil)
+
 	log.Info().
-		Str("role_id", w.messages[idxMessageFound].RoleID).
-		Str("role", w.messages[idxMessageFound].Role).
-		Str("channel_id", reaction.ChannelID).
-		Str("message_id", reaction.MessageID).
-		Str("user_id", reaction.UserID).
+		Str("role_id", reaction.RoleID).
+		Str("role", reaction.Role).
+		Str("channel_id", channelID).
+		Str("message_id", messageID).
+		Str("user_id", userID).
 		Msg("discord_bot.welcome.user_role_removed")
+
+	return nil
 }
 
-func (w *Manager) isMessageReactionMatching(messageReaction *discordgo.MessageReaction) (int, bool) {
-	if messageReaction.ChannelID != w.channelID {
-		return -1, false
+// setMemberRoleInState updates roles of member in state without waiting for the event of Discord,
+// reactions removed by the bot right after are then seen as removed with the role.
+func (w *Manager) setMemberRoleInState(userID string, roleID string, hasRole bool) {
+	member, err := w.discordSession.State.Member(w.guildID, userID)
+	if err != nil {
+		return
 	}
 
+	memberUpdated := *member
+	memberUpdated.GuildID = w.guildID
+	memberUpdated.Roles = slices.DeleteFunc(slices.Clone(member.Roles), func(role string) bool { return role == roleID })
+
+	if hasRole {
+		memberUpdated.Roles = append(memberUpdated.Roles, roleID)
+	}
+
+	//nolint:errcheck
+	w.discordSession.State.MemberAdd(&memberUpdated)
+}
+
+func (w *Manager) isMessageComponentMatching(channelID string, messageID string) (Message, bool) {
+	for _, message := range w.messages {
+		if message.ChannelID == channelID && message.ID == messageID && message.usesComponents() {
+			return message, true
+		}
+	}
+
+	return Message{}, false
+}
+
+func (w *Manager) isMessageReactionMatching(messageReaction *discordgo.MessageReaction) (Message, Reaction, bool) {
 	if w.isUserBot(messageReaction.UserID) {
-		return -1, false
+		return Message{}, Reaction{}, false
 	}
 
 	idxMessageFound := -1
 
 	for idxMessage := range w.messages {
-		if messageReaction.MessageID == w.messages[idxMessage].ID {
+		if messageReaction.ChannelID == w.messages[idxMessage].ChannelID && messageReaction.MessageID == w.messages[idxMessage].ID {
 			idxMessageFound = idxMessage
 
 			break
 		}
 	}
 
-	if idxMessageFound == -1 {
-		return -1, false
+	if idxMessageFound == -1 || w.messages[idxMessageFound].usesComponents() {
+		return Message{}, Reaction{}, false
 	}
 
-	if messageReaction.Emoji.Name != w.messages[idxMessageFound].Emoji {
-		return -1, false
+	for _, reaction := range w.messages[idxMessageFound].Reactions {
+		if reaction.isSameEmoji(messageReaction.Emoji) {
+			return w.messages[idxMessageFound], reaction, true
+		}
 	}
 
-	return idxMessageFound, true
+	return Message{}, Reaction{}, false
 }
@@ -30,10 +30,10 @@ func TestHandlers_OnMessageReactionAdd(t *testing.T) {
 		Name:     guildName,
 		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
 		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
-		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
 		Members: []*discordgo.Member{
 			{User: &discordgo.User{ID: "user-id-456"}},
-			{User: &discordgo.User{ID: "bot-123"}},
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}},
 			{User: &discordgo.User{ID: "user-id-789"}, Roles: []string{"role-123"}},
 		},
 	})
@@ -225,10 +225,10 @@ func TestHandlers_OnMessageReactionAdd_Errors(t *testing.T) {
 		Name:     guildName,
 		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
 		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
-		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
 		Members: []*discordgo.Member{
 			{User: &discordgo.User{ID: "user-id-456"}},
-			{User: &discordgo.User{ID: "bot-123"}},
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}},
 			{User: &discordgo.User{ID: "user-id-789"}, Roles: []string{"role-123"}},
 		},
 	})
@@ -333,10 +333,10 @@ func TestHandlers_OnMessageReactionRemove(t *testing.T) {
 		Name:     guildName,
 		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
 		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
-		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
 		Members: []*discordgo.Member{
 			{User: &discordgo.User{ID: "user-id-456"}},
-			{User: &discordgo.User{ID: "bot-123"}},
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}},
 			{User: &discordgo.User{ID: "user-id-789"}, Roles: []string{"role-123"}},
 		},
 	})
@@ -506,6 +506,42 @@ func TestHandlers_OnMessageReactionRemove(t *testing.T) {
 		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","channel_id":"channel-123","message_id":"123","user_id":"user-id-789","message":"discord_bot.welcome.user_role_removed"}`, parts[2])
 		require.Empty(t, parts[3])
 	})
+
+	t.Run("should not remove role because member does not have it anymore", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager.OnMessageReactionRemove(nil, &discordgo.MessageReactionRemove{
+			MessageReaction: &discordgo.MessageReaction{
+				ChannelID: "channel-123",
+				UserID:    "user-id-789",
+				MessageID: "123",
+				Emoji:     discordgo.Emoji{Name: "my-emoji-1"},
+			},
+		})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug", "message":"discord_bot.welcome.event_message_reaction_remove_received"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-789","is_member":true,"message":"discord_bot.welcome.user_role_removing_skipped"}`, parts[1])
+		require.Empty(t, parts[2])
+	})
+
+	t.Run("should not remove role because member left", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager.OnMessageReactionRemove(nil, &discordgo.MessageReactionRemove{
+			MessageReaction: &discordgo.MessageReaction{
+				ChannelID: "channel-123",
+				UserID:    "user-id-999",
+				MessageID: "123",
+				Emoji:     discordgo.Emoji{Name: "my-emoji-1"},
+			},
+		})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug", "message":"discord_bot.welcome.event_message_reaction_remove_received"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-999","is_member":false,"message":"discord_bot.welcome.user_role_removing_skipped"}`, parts[1])
+		require.Empty(t, parts[2])
+	})
 }
 
 func TestHandlers_OnMessageReactionRemove_Errors(t *testing.T) {
@@ -521,10 +557,10 @@ func TestHandlers_OnMessageReactionRemove_Errors(t *testing.T) {
 		Name:     guildName,
 		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
 		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
-		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
 		Members: []*discordgo.Member{
 			{User: &discordgo.User{ID: "user-id-456"}},
-			{User: &discordgo.User{ID: "bot-123"}},
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}},
 			{User: &discordgo.User{ID: "user-id-789"}, Roles: []string{"role-123"}},
 		},
 	})
@@ -614,3 +650,336 @@ func TestHandlers_OnMessageReactionRemove_Errors(t *testing.T) {
 	require.JSONEq(t, `{"level":"error","error":"HTTP 500 Internal Server Error, ","role_id":"role-123","role":"my role 1","channel_id":"channel-123","message_id":"123","user_id":"user-id-789","message":"discord_bot.welcome.user_role_removing_failed"}`, parts[2])
 	require.Empty(t, parts[3])
 }
+
+func TestHandlers_OnMessageReactionAdd_UnicodeEmoji(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "user-id-456"}},
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}},
+		},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{Title: "my title 1", Emoji: "❤", Role: "my role 1"},
+		},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	data1, err := json.Marshal([]*discordgo.Message{
+		{
+			ID:     "123",
+			Author: &discordgo.User{ID: "bot-123"},
+			Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}},
+		},
+	})
+	require.NoError(t, err)
+
+	recorder1 := httptest.NewRecorder()
+	recorder1.Header().Add("Content-Type", "application/json")
+	_, err = recorder1.Write(data1)
+	require.NoError(t, err)
+
+	expectedResponse1 := recorder1.Result()
+	defer expectedResponse1.Body.Close()
+
+	data2, err := json.Marshal([]discordgo.User{})
+	require.NoError(t, err)
+
+	recorder2 := httptest.NewRecorder()
+	recorder2.Header().Add("Content-Type", "application/json")
+	_, err = recorder2.Write(data2)
+	require.NoError(t, err)
+
+	expectedResponse2 := recorder2.Result()
+	defer expectedResponse2.Body.Close()
+
+	recorder3 := httptest.NewRecorder()
+
+	expectedResponse3 := recorder3.Result()
+	defer expectedResponse3.Body.Close()
+
+	session.Client = createClient(t,
+		[]*http.Response{expectedResponse1, expectedResponse2, expectedResponse3},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/%E2%9D%A4?limit=100"},
+			{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-123"},
+		},
+	)
+
+	err = welcomeManager.Run()
+	require.NoError(t, err)
+
+	t.Run("should stop process because unicode emoji is not matching", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager.OnMessageReactionAdd(nil, &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{
+				ChannelID: "channel-123",
+				UserID:    "user-id-456",
+				MessageID: "123",
+				Emoji:     discordgo.Emoji{Name: "✅"},
+			},
+		})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug", "message":"discord_bot.welcome.event_message_reaction_add_received"}`, parts[0])
+		require.Empty(t, parts[1])
+	})
+
+	t.Run("should add role to user even with variation selector on unicode emoji", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager.OnMessageReactionAdd(nil, &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{
+				ChannelID: "channel-123",
+				UserID:    "user-id-456",
+				MessageID: "123",
+				Emoji:     discordgo.Emoji{Name: "❤️"},
+			},
+		})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug", "message":"discord_bot.welcome.event_message_reaction_add_received"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_adding"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_added"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+}
+
+func TestHandlers_MultipleReactions(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-456", Name: "my role 2"}},
+		Members:  []*discordgo.Member{{User: &discordgo.User{ID: "user-id-456"}, Roles: []string{"role-123"}}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{Title: "my title 1", Reactions: []welcome.Reaction{{Emoji: "my-emoji-1", Role: "my role 1"}, {Emoji: "✅", Role: "my role 2"}}},
+		},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	session.Client = createClient(t,
+		[]*http.Response{
+			createJSONResponse(t, []*discordgo.Message{
+				{ID: "123", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+			}),
+			createJSONResponse(t, []discordgo.User{}),
+			createJSONResponse(t, []discordgo.User{}),
+			createEmptyResponse(t),
+			createErrorResponse(t),
+		},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/my-emoji-1:emoji-123?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/%E2%9C%85?limit=100"},
+			{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-456"},
+			{method: "DELETE", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-123"},
+		},
+	)
+
+	err = welcomeManager.Run()
+	require.NoError(t, err)
+
+	t.Run("should add role matching the second reaction", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager.OnMessageReactionAdd(nil, &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{
+				ChannelID: "channel-123",
+				UserID:    "user-id-456",
+				MessageID: "123",
+				Emoji:     discordgo.Emoji{Name: "✅"},
+			},
+		})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug", "message":"discord_bot.welcome.event_message_reaction_add_received"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","role_id":"role-456","role":"my role 2","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_adding"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","role_id":"role-456","role":"my role 2","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_added"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should remove role matching the first reaction", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager.OnMessageReactionRemove(nil, &discordgo.MessageReactionRemove{
+			MessageReaction: &discordgo.MessageReaction{
+				ChannelID: "channel-123",
+				UserID:    "user-id-456",
+				MessageID: "123",
+				Emoji:     discordgo.Emoji{ID: "emoji-123", Name: "my-emoji-1"},
+			},
+		})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug", "message":"discord_bot.welcome.event_message_reaction_remove_received"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_removing"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","error":"HTTP 500 Internal Server Error, ","role_id":"role-123","role":"my role 1","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_removing_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+}
+
+func TestHandlers_OnGuildMemberRemove(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-456", Name: "my role 2"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}},
+			{User: &discordgo.User{ID: "user-id-456"}, Roles: []string{"role-123", "role-456"}},
+		},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{Title: "my title 1", Reactions: []welcome.Reaction{{Emoji: "my-emoji-1", Role: "my role 1"}, {Emoji: "👍", Role: "my role 2"}}, CanPurgeReactions: true},
+			{Title: "my title 2", Emoji: "my-emoji-1", Role: "my role 2"},
+		},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	session.Client = createClient(t,
+		[]*http.Response{
+			createJSONResponse(t, []*discordgo.Message{
+				{ID: "123", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+				{ID: "456", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 2"}}},
+			}),
+			createJSONResponse(t, []discordgo.User{{ID: "user-id-456"}}),
+			createJSONResponse(t, []discordgo.User{}),
+			createJSONResponse(t, []discordgo.User{{ID: "user-id-456"}}),
+			createJSONResponse(t, []discordgo.User{}),
+			createJSONResponse(t, []discordgo.User{{ID: "user-id-456"}}),
+			createJSONResponse(t, []discordgo.User{}),
+		},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/my-emoji-1:emoji-123?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/my-emoji-1:emoji-123?after=user-id-456&limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/%F0%9F%91%8D?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/%F0%9F%91%8D?after=user-id-456&limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/456/reactions/my-emoji-1:emoji-123?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/456/reactions/my-emoji-1:emoji-123?after=user-id-456&limit=100"},
+		},
+	)
+
+	err = welcomeManager.Run()
+	require.NoError(t, err)
+
+	t.Run("should do nothing because member is from another guild", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager.OnGuildMemberRemove(nil, &discordgo.GuildMemberRemove{Member: &discordgo.Member{GuildID: "guild-999", User: &discordgo.User{ID: "user-id-456"}}})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug","message":"discord_bot.welcome.event_guild_member_remove_received"}`, parts[0])
+		require.Empty(t, parts[1])
+	})
+
+	t.Run("should remove reactions of member only on messages with can_purge_reactions", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{createEmptyResponse(t), createErrorResponse(t)},
+			[]requestTest{
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/my-emoji-1:emoji-123/user-id-456"},
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/%F0%9F%91%8D/user-id-456"},
+			},
+		)
+
+		// state removes the member before handlers are called
+		err = session.State.MemberRemove(&discordgo.Member{GuildID: "guild-123", User: &discordgo.User{ID: "user-id-456"}})
+		require.NoError(t, err)
+
+		welcomeManager.OnGuildMemberRemove(nil, &discordgo.GuildMemberRemove{Member: &discordgo.Member{GuildID: "guild-123", User: &discordgo.User{ID: "user-id-456"}}})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug","message":"discord_bot.welcome.event_guild_member_remove_received"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","message_id":"123","emoji":"my-emoji-1:emoji-123","user_id":"user-id-456","message":"discord_bot.welcome.departed_member_reaction_removing"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","message_id":"123","emoji":"👍","user_id":"user-id-456","message":"discord_bot.welcome.departed_member_reaction_removing"}`, parts[2])
+		require.JSONEq(t, `{"level":"error","error":"HTTP 500 Internal Server Error, ","message_id":"123","emoji":"👍","user_id":"user-id-456","message":"discord_bot.welcome.departed_member_reaction_removing_failed"}`, parts[3])
+		require.Empty(t, parts[4])
+	})
+	t.Run("should not remove role when reaction removed of departed member is received", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t, nil, nil)
+
+		welcomeManager.OnMessageReactionRemove(nil, &discordgo.MessageReactionRemove{
+			MessageReaction: &discordgo.MessageReaction{
+				ChannelID: "channel-123",
+				UserID:    "user-id-456",
+				MessageID: "123",
+				Emoji:     discordgo.Emoji{ID: "emoji-123", Name: "my-emoji-1"},
+			},
+		})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug","message":"discord_bot.welcome.event_message_reaction_remove_received"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-456","is_member":false,"message":"discord_bot.welcome.user_role_removing_skipped"}`, parts[1])
+		require.Empty(t, parts[2])
+	})
+
+	t.Run("should not remove reactions of member who did not react", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t, nil, nil)
+
+		welcomeManager.OnGuildMemberRemove(nil, &discordgo.GuildMemberRemove{Member: &discordgo.Member{GuildID: "guild-123", User: &discordgo.User{ID: "user-id-789"}}})
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"debug","message":"discord_bot.welcome.event_guild_member_remove_received"}`, parts[0])
+		require.Empty(t, parts[1])
+	})
+}
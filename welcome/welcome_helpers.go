@@ -1,5 +1,43 @@
 package welcome
 
+import (
+	"strings"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const variationSelector = "\uFE0F"
+
 func (w *Manager) isUserBot(userID string) bool {
 	return w.discordSession.State.User.ID == userID
 }
+
+// isUnicodeEmoji returns true when emoji is a standard emoji and not a custom guild emoji name.
+// Custom emoji names are only made of ASCII letters, digits and underscores.
+func isUnicodeEmoji(emoji string) bool {
+	for _, r := range emoji {
+		if r > unicode.MaxASCII {
+			return true
+		}
+	}
+
+	return false
+}
+
+// emojiAPIName returns the emoji format expected by Discord API: "name:id" for custom emoji, the emoji itself for unicode.
+func (r Reaction) emojiAPIName() string {
+	if r.EmojiID == "" {
+		return r.Emoji
+	}
+
+	return r.Emoji + ":" + r.EmojiID
+}
+
+func (r Reaction) isSameEmoji(emojiFromDiscord discordgo.Emoji) bool {
+	if r.EmojiID != "" && emojiFromDiscord.ID != "" {
+		return emojiFromDiscord.ID == r.EmojiID
+	}
+
+	return strings.ReplaceAll(emojiFromDiscord.Name, variationSelector, "") == strings.ReplaceAll(r.Emoji, variationSelector, "")
+}
@@ -0,0 +1,263 @@
+package welcome
+
+import (
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+type permission struct {
+	value int64
+	name  string
+}
+
+var (
+	permissionManageRoles = permission{value: discordgo.PermissionManageRoles, name: "Manage Roles"}
+
+	// permissionsChannel are needed in each channel to publish, find and edit messages.
+	permissionsChannel = []permission{
+		{value: discordgo.PermissionViewChannel, name: "View Channel"},
+		{value: discordgo.PermissionSendMessages, name: "Send Messages"},
+		{value: discordgo.PermissionEmbedLinks, name: "Embed Links"},
+		{value: discordgo.PermissionReadMessageHistory, name: "Read Message History"},
+	}
+
+	// permissionAddReactions is only needed in channels with messages using reactions.
+	permissionAddReactions = permission{value: discordgo.PermissionAddReactions, name: "Add Reactions"}
+
+	// permissionManageMessages is only needed in channels with messages removing reactions of members.
+	permissionManageMessages = permission{value: discordgo.PermissionManageMessages, name: "Manage Messages"}
+
+	// permissionsGreetingChannel are needed in the greeting channel to greet members.
+	permissionsGreetingChannel = []permission{
+		{value: discordgo.PermissionViewChannel, name: "View Channel"},
+		{value: discordgo.PermissionSendMessages, name: "Send Messages"},
+	}
+
+	// permissionsAuditChannel are needed in the audit channel to post role changes.
+	permissionsAuditChannel = []permission{
+		{value: discordgo.PermissionViewChannel, name: "View Channel"},
+		{value: discordgo.PermissionSendMessages, name: "Send Messages"},
+		{value: discordgo.PermissionEmbedLinks, name: "Embed Links"},
+	}
+)
+
+// hasPermissionsAgainstDiscordServer checks that the bot can give each role and publish messages in each channel.
+// Roles and channel permission overwrites come from state, the check is skipped if the bot is not a member in state.
+func (w *Manager) hasPermissionsAgainstDiscordServer(guild *discordgo.Guild) bool {
+	botMember := w.botMember(guild)
+	if botMember == nil {
+		log.Warn().
+			Str("guild", w.guildName).
+			Msg("discord_bot.welcome.permissions_check_skipped")
+
+		return true
+	}
+
+	if !hasPermission(guildPermissions(guild, botMember), permissionManageRoles) {
+		log.Error().
+			Str("permission", permissionManageRoles.name).
+			Msg("discord_bot.welcome.configuration_permission_missed")
+
+		return false
+	}
+
+	botPosition := highestRolePosition(guild, botMember)
+
+	for idx, message := range w.messages {
+		for idxReaction, reaction := range message.Reactions {
+			if !canGiveRole(guild, botMember, botPosition, idx, idxReaction, reaction) {
+				return false
+			}
+		}
+	}
+
+	for _, channelID := range w.channelIDs() {
+		if !w.hasChannelPermissions(guild, botMember, channelID) {
+			return false
+		}
+	}
+
+	if w.auditChannelID != "" && !hasChannelPermissionsNeeded(guild, botMember, w.auditChannelID, permissionsAuditChannel) {
+		return false
+	}
+
+	if w.greeting != nil && w.greeting.ChannelID != "" && !hasChannelPermissionsNeeded(guild, botMember, w.greeting.ChannelID, permissionsGreetingChannel) {
+		return false
+	}
+
+	return true
+}
+
+func (w *Manager) botMember(guild *discordgo.Guild) *discordgo.Member {
+	if w.discordSession.State.User == nil {
+		return nil
+	}
+
+	for _, member := range guild.Members {
+		if member.User != nil && member.User.ID == w.discordSession.State.User.ID {
+			return member
+		}
+	}
+
+	return nil
+}
+
+func canGiveRole(guild *discordgo.Guild, botMember *discordgo.Member, botPosition int, idxMessage int, idxReaction int, reaction Reaction) bool {
+	idxRole := slices.IndexFunc(guild.Roles, func(role *discordgo.Role) bool { return role.ID == reaction.RoleID })
+	if idxRole == -1 {
+		return true
+	}
+
+	role := guild.Roles[idxRole]
+
+	if role.Managed {
+		log.Error().
+			Int("message index", idxMessage).
+			Int("reaction index", idxReaction).
+			Str("role_id", role.ID).
+			Str("role", role.Name).
+			Str("help", "This role is managed by an integration and cannot be given to members").
+			Msg("discord_bot.welcome.configuration_role_managed")
+
+		return false
+	}
+
+	if botMember.User.ID != guild.OwnerID && role.Position >= botPosition {
+		log.Error().
+			Int("message index", idxMessage).
+			Int("reaction index", idxReaction).
+			Str("role_id", role.ID).
+			Str("role", role.Name).
+			Int("role_position", role.Position).
+			Int("bot_role_position", botPosition).
+			Str("help", "Move the highest role of the bot above this role in server settings").
+			Msg("discord_bot.welcome.configuration_role_above_bot")
+
+		return false
+	}
+
+	return true
+}
+
+func (w *Manager) hasChannelPermissions(guild *discordgo.Guild, botMember *discordgo.Member, channelID string) bool {
+	permissionsNeeded := slices.Clone(permissionsChannel)
+	if slices.ContainsFunc(w.messages, func(message Message) bool { return message.ChannelID == channelID && !message.usesComponents() }) {
+		permissionsNeeded = append(permissionsNeeded, permissionAddReactions)
+	}
+
+	if slices.ContainsFunc(w.messages, func(message Message) bool {
+		return message.ChannelID == channelID && w.removesReactionsOfMembers(message)
+	}) {
+		permissionsNeeded = append(permissionsNeeded, permissionManageMessages)
+	}
+
+	return hasChannelPermissionsNeeded(guild, botMember, channelID, permissionsNeeded)
+}
+
+// removesReactionsOfMembers is true when the purge, an exclusive group or the age gate removes reactions of members on message.
+func (w *Manager) removesReactionsOfMembers(message Message) bool {
+	if message.usesComponents() {
+		return false
+	}
+
+	return message.CanPurgeReactions || w.isExclusiveGroup(message.Group) || message.MinAccountAge != "" || message.MinMembershipAge != ""
+}
+
+func hasChannelPermissionsNeeded(guild *discordgo.Guild, botMember *discordgo.Member, channelID string, permissionsNeeded []permission) bool {
+	idxChannel := slices.IndexFunc(guild.Channels, func(channel *discordgo.Channel) bool { return channel.ID == channelID })
+	if idxChannel == -1 {
+		return true
+	}
+
+	permissions := channelPermissions(guild, guild.Channels[idxChannel], botMember)
+
+	for _, permissionNeeded := range permissionsNeeded {
+		if hasPermission(permissions, permissionNeeded) {
+			continue
+		}
+
+		log.Error().
+			Str("permission", permissionNeeded.name).
+			Str("channel_id", channelID).
+			Str("channel", guild.Channels[idxChannel].Name).
+			Msg("discord_bot.welcome.configuration_channel_permission_missed")
+
+		return false
+	}
+
+	return true
+}
+
+func hasPermission(permissions int64, permissionNeeded permission) bool {
+	return permissions&permissionNeeded.value == permissionNeeded.value
+}
+
+// highestRolePosition returns the position of the highest role of member, @everyone is at position 0.
+func highestRolePosition(guild *discordgo.Guild, member *discordgo.Member) int {
+	position := 0
+
+	for _, role := range guild.Roles {
+		if slices.Contains(member.Roles, role.ID) && role.Position > position {
+			position = role.Position
+		}
+	}
+
+	return position
+}
+
+// guildPermissions returns permissions of member from @everyone and its roles.
+func guildPermissions(guild *discordgo.Guild, member *discordgo.Member) int64 {
+	if member.User.ID == guild.OwnerID {
+		return discordgo.PermissionAll
+	}
+
+	var permissions int64
+
+	for _, role := range guild.Roles {
+		if role.ID == guild.ID || slices.Contains(member.Roles, role.ID) {
+			permissions |= role.Permissions
+		}
+	}
+
+	if hasPermission(permissions, permission{value: discordgo.PermissionAdministrator}) {
+		return discordgo.PermissionAll
+	}
+
+	return permissions
+}
+
+// channelPermissions applies permission overwrites of channel to guild permissions of member.
+// Overwrites are applied in Discord order: @everyone, then roles of member, then member.
+func channelPermissions(guild *discordgo.Guild, channel *discordgo.Channel, member *discordgo.Member) int64 {
+	permissions := guildPermissions(guild, member)
+	if hasPermission(permissions, permission{value: discordgo.PermissionAdministrator}) {
+		return permissions
+	}
+
+	var allows, denies int64
+
+	for _, overwrite := range channel.PermissionOverwrites {
+		switch {
+		case overwrite.ID == guild.ID:
+			permissions &^= overwrite.Deny
+			permissions |= overwrite.Allow
+		case overwrite.Type == discordgo.PermissionOverwriteTypeRole && slices.Contains(member.Roles, overwrite.ID):
+			denies |= overwrite.Deny
+			allows |= overwrite.Allow
+		}
+	}
+
+	permissions &^= denies
+	permissions |= allows
+
+	for _, overwrite := range channel.PermissionOverwrites {
+		if overwrite.Type == discordgo.PermissionOverwriteTypeMember && overwrite.ID == member.User.ID {
+			permissions &^= overwrite.Deny
+			permissions |= overwrite.Allow
+		}
+	}
+
+	return permissions
+}
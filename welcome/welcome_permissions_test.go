@@ -0,0 +1,186 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+const permissionsBot = discordgo.PermissionManageRoles |
+	discordgo.PermissionViewChannel |
+	discordgo.PermissionSendMessages |
+	discordgo.PermissionEmbedLinks |
+	discordgo.PermissionReadMessageHistory |
+	discordgo.PermissionAddReactions
+
+//nolint:funlen
+func TestNewWelcomeManager_Permissions(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	createSession := func(t *testing.T, roles []*discordgo.Role, overwrites []*discordgo.PermissionOverwrite) *discordgo.Session {
+		t.Helper()
+
+		session, err := discordgo.New("fake-token")
+		require.NoError(t, err)
+
+		session.State.Guilds = append(session.State.Guilds, &discordgo.Guild{
+			ID:       "guild-123",
+			Name:     guildName,
+			Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel", PermissionOverwrites: overwrites}},
+			Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+			Roles:    roles,
+			Members:  []*discordgo.Member{{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}}},
+		})
+
+		session.State.User = &discordgo.User{
+			ID: "bot-123",
+		}
+
+		return session
+	}
+
+	config := welcome.Configuration{
+		Channel:  "my-channel",
+		Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+	}
+
+	t.Run("should return manager because bot can give role and react in channel", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session := createSession(t, []*discordgo.Role{
+			{ID: "guild-123", Name: "@everyone", Permissions: discordgo.PermissionViewChannel},
+			{ID: "role-123", Name: "my role 1", Position: 1},
+			{ID: "role-bot", Name: "bot", Position: 2, Permissions: permissionsBot},
+		}, nil)
+
+		welcomeManager := welcome.NewWelcomeManager(config, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.configuration_validated"}`, parts[5])
+		require.Empty(t, parts[6])
+	})
+
+	t.Run("should return nil because bot has not Manage Roles", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session := createSession(t, []*discordgo.Role{
+			{ID: "role-123", Name: "my role 1", Position: 1},
+			{ID: "role-bot", Name: "bot", Position: 2, Permissions: permissionsBot &^ discordgo.PermissionManageRoles},
+		}, nil)
+
+		welcomeManager := welcome.NewWelcomeManager(config, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","permission":"Manage Roles","message":"discord_bot.welcome.configuration_permission_missed"}`, parts[5])
+		require.JSONEq(t, `{"level":"error","step":2,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[6])
+		require.Empty(t, parts[7])
+	})
+
+	t.Run("should return nil because role is above the highest role of bot", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session := createSession(t, []*discordgo.Role{
+			{ID: "role-123", Name: "my role 1", Position: 3},
+			{ID: "role-bot", Name: "bot", Position: 2, Permissions: permissionsBot},
+		}, nil)
+
+		welcomeManager := welcome.NewWelcomeManager(config, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","message index":0,"reaction index":0,"role_id":"role-123","role":"my role 1","role_position":3,"bot_role_position":2,"help":"Move the highest role of the bot above this role in server settings","message":"discord_bot.welcome.configuration_role_above_bot"}`, parts[5])
+	})
+
+	t.Run("should return nil because role is managed by an integration", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session := createSession(t, []*discordgo.Role{
+			{ID: "role-123", Name: "my role 1", Position: 1, Managed: true},
+			{ID: "role-bot", Name: "bot", Position: 2, Permissions: permissionsBot},
+		}, nil)
+
+		welcomeManager := welcome.NewWelcomeManager(config, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","message index":0,"reaction index":0,"role_id":"role-123","role":"my role 1","help":"This role is managed by an integration and cannot be given to members","message":"discord_bot.welcome.configuration_role_managed"}`, parts[5])
+	})
+
+	t.Run("should return nil because reactions are denied in channel", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session := createSession(t, []*discordgo.Role{
+			{ID: "role-123", Name: "my role 1", Position: 1},
+			{ID: "role-bot", Name: "bot", Position: 2, Permissions: permissionsBot},
+		}, []*discordgo.PermissionOverwrite{
+			{ID: "role-bot", Type: discordgo.PermissionOverwriteTypeRole, Deny: discordgo.PermissionAddReactions},
+		})
+
+		welcomeManager := welcome.NewWelcomeManager(config, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","permission":"Add Reactions","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.configuration_channel_permission_missed"}`, parts[5])
+	})
+
+	t.Run("should return nil because bot has not Manage Messages to purge reactions", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session := createSession(t, []*discordgo.Role{
+			{ID: "role-123", Name: "my role 1", Position: 1},
+			{ID: "role-bot", Name: "bot", Position: 2, Permissions: permissionsBot},
+		}, nil)
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1", CanPurgeReactions: true}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","permission":"Manage Messages","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.configuration_channel_permission_missed"}`, parts[5])
+	})
+
+	t.Run("should return manager because bot has Manage Messages to refuse reactions of young accounts", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session := createSession(t, []*discordgo.Role{
+			{ID: "role-123", Name: "my role 1", Position: 1},
+			{ID: "role-bot", Name: "bot", Position: 2, Permissions: permissionsBot | discordgo.PermissionManageMessages},
+		}, nil)
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1", MinAccountAge: "168h"}},
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+	})
+
+	t.Run("should return manager because bot member is allowed in channel", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session := createSession(t, []*discordgo.Role{
+			{ID: "guild-123", Name: "@everyone"},
+			{ID: "role-123", Name: "my role 1", Position: 1},
+			{ID: "role-bot", Name: "bot", Position: 2, Permissions: discordgo.PermissionManageRoles},
+		}, []*discordgo.PermissionOverwrite{
+			{ID: "guild-123", Type: discordgo.PermissionOverwriteTypeRole, Deny: discordgo.PermissionViewChannel},
+			{ID: "bot-123", Type: discordgo.PermissionOverwriteTypeMember, Allow: permissionsBot},
+		})
+
+		welcomeManager := welcome.NewWelcomeManager(config, guildName, session)
+		require.NotNil(t, welcomeManager)
+	})
+}
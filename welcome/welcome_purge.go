@@ -0,0 +1,55 @@
+package welcome
+
+import (
+	"sync"
+)
+
+// reactionOfMember is a reaction on a message with CanPurgeReactions.
+type reactionOfMember struct {
+	messageID string
+	emoji     string
+}
+
+// reactionsOfMembers keeps reactions of members on messages with CanPurgeReactions: user ID -> reactions.
+// Only reactions of a departed member are removed, instead of trying each reaction of each message.
+type reactionsOfMembers struct {
+	mutex     sync.Mutex
+	reactions map[string]map[reactionOfMember]struct{}
+}
+
+func (r *reactionsOfMembers) add(userID string, messageID string, emoji string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.reactions == nil {
+		r.reactions = map[string]map[reactionOfMember]struct{}{}
+	}
+
+	if r.reactions[userID] == nil {
+		r.reactions[userID] = map[reactionOfMember]struct{}{}
+	}
+
+	r.reactions[userID][reactionOfMember{messageID: messageID, emoji: emoji}] = struct{}{}
+}
+
+func (r *reactionsOfMembers) remove(userID string, messageID string, emoji string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.reactions[userID], reactionOfMember{messageID: messageID, emoji: emoji})
+
+	if len(r.reactions[userID]) == 0 {
+		delete(r.reactions, userID)
+	}
+}
+
+// forget returns reactions of user and forgets them.
+func (r *reactionsOfMembers) forget(userID string) map[reactionOfMember]struct{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	reactions := r.reactions[userID]
+	delete(r.reactions, userID)
+
+	return reactions
+}
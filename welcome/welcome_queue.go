@@ -0,0 +1,417 @@
+package welcome
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultRoleRetries    int           = 5
+	defaultRoleRetryDelay time.Duration = time.Second
+	maxRoleRetryDelay     time.Duration = time.Minute
+
+	defaultRoleQueueMetricsInterval time.Duration = 5 * time.Minute
+)
+
+// roleChange is a role to add or remove after a reaction, attempt is the number of retries already done.
+type roleChange struct {
+	add       bool
+	channelID string
+	messageID string
+	userID    string
+	reaction  Reaction
+	group     string
+	attempt   int
+	version   uint64
+}
+
+// roleChangeKey identifies the role of a user, only the last role change queued for a key is applied.
+type roleChangeKey struct {
+	userID string
+	roleID string
+}
+
+func (c roleChange) key() roleChangeKey {
+	return roleChangeKey{userID: c.userID, roleID: c.reaction.RoleID}
+}
+
+// roleQueue applies role changes one at a time, failed changes are queued again after a delay.
+// versions keeps the version of the last role change queued for each key, 0 when it was canceled.
+type roleQueue struct {
+	changes       chan roleChange
+	stop          chan struct{}
+	stopped       chan struct{}
+	retrying      atomic.Int64
+	applied       atomic.Int64
+	retried       atomic.Int64
+	failed        atomic.Int64
+	versionsMutex sync.Mutex
+	versions      map[roleChangeKey]uint64
+	lastVersion   uint64
+}
+
+// newVersion makes older role changes of key superseded.
+func (q *roleQueue) newVersion(key roleChangeKey) uint64 {
+	q.versionsMutex.Lock()
+	defer q.versionsMutex.Unlock()
+
+	q.lastVersion++
+	q.versions[key] = q.lastVersion
+
+	return q.lastVersion
+}
+
+// cancel makes role changes of key waiting in the queue or for a retry superseded.
+func (q *roleQueue) cancel(key roleChangeKey) {
+	q.versionsMutex.Lock()
+	defer q.versionsMutex.Unlock()
+
+	_, ok := q.versions[key]
+	if ok {
+		q.versions[key] = 0
+	}
+}
+
+func (q *roleQueue) isSuperseded(change roleChange) bool {
+	q.versionsMutex.Lock()
+	defer q.versionsMutex.Unlock()
+
+	version := q.versions[change.key()]
+	if version == change.version {
+		return false
+	}
+
+	if version == 0 {
+		delete(q.versions, change.key())
+	}
+
+	return true
+}
+
+// done forgets key when change is the last role change queued for it.
+func (q *roleQueue) done(change roleChange) {
+	q.versionsMutex.Lock()
+	defer q.versionsMutex.Unlock()
+
+	if q.versions[change.key()] == change.version {
+		delete(q.versions, change.key())
+	}
+}
+
+// RoleQueueMetrics is a snapshot of the role queue.
+// Depth is the number of role changes waiting, Retrying the number of role changes waiting for their delay before a retry.
+// Applied, Retried and Failed are counted since the start, a role change is failed when it cannot be retried anymore.
+type RoleQueueMetrics struct {
+	Depth    int
+	Retrying int64
+	Applied  int64
+	Retried  int64
+	Failed   int64
+}
+
+// RoleQueueMetrics returns metrics of the role queue, they are empty when the queue is not used.
+func (w *Manager) RoleQueueMetrics() RoleQueueMetrics {
+	if w.roleQueue == nil {
+		return RoleQueueMetrics{}
+	}
+
+	return RoleQueueMetrics{
+		Depth:    len(w.roleQueue.changes),
+		Retrying: w.roleQueue.retrying.Load(),
+		Applied:  w.roleQueue.applied.Load(),
+		Retried:  w.roleQueue.retried.Load(),
+		Failed:   w.roleQueue.failed.Load(),
+	}
+}
+
+// changeUserRole queues the role change when the role queue is used, otherwise the role change is applied immediately.
+func (w *Manager) changeUserRole(change roleChange) {
+	if w.roleQueue == nil {
+		err := w.applyRoleChange(change)
+		if err != nil {
+			w.auditRoleChangeFailed(change, err)
+		}
+
+		return
+	}
+
+	change.version = w.roleQueue.newVersion(change.key())
+
+	w.enqueueRoleChange(change)
+}
+
+// cancelRoleChanges drops role changes of the role of user not applied yet, when a newer event makes them obsolete.
+func (w *Manager) cancelRoleChanges(userID string, roleID string) {
+	if w.roleQueue == nil {
+		return
+	}
+
+	w.roleQueue.cancel(roleChangeKey{userID: userID, roleID: roleID})
+}
+
+// applyRoleChange adds or removes the role, other roles of an exclusive group are removed after a role is added.
+// A failure is not audited, only the last attempt of a role change is audited by auditRoleChangeFailed.
+func (w *Manager) applyRoleChange(change roleChange) error {
+	if !change.add {
+		return w.requestUserRoleRemove(change.channelID, change.messageID, change.userID, change.reaction)
+	}
+
+	err := w.requestUserRoleAdd(change.channelID, change.messageID, change.userID, change.reaction)
+	if err != nil {
+		return err
+	}
+
+	if w.isExclusiveGroup(change.group) {
+		w.removeOtherRolesInExclusiveGroup(change.group, change.reaction, change.userID, true)
+	}
+
+	return nil
+}
+
+func (w *Manager) auditRoleChangeFailed(change roleChange, err error) {
+	if change.add {
+		w.audit(auditRoleAddingFailed, change.userID, change.reaction.RoleID, err)
+
+		return
+	}
+
+	w.audit(auditRoleRemovingFailed, change.userID, change.reaction.RoleID, err)
+}
+
+// enqueueRoleChange waits for a free place in the queue, role changes are only dropped when the queue is stopped.
+func (w *Manager) enqueueRoleChange(change roleChange) {
+	select {
+	case <-w.roleQueue.stop:
+		w.dropRoleChange(change)
+
+		return
+	default:
+	}
+
+	log.Debug().
+		Str("role_id", change.reaction.RoleID).
+		Str("user_id", change.userID).
+		Int("queue_depth", len(w.roleQueue.changes)).
+		Msg("discord_bot.welcome.role_change_queuing")
+
+	select {
+	case w.roleQueue.changes <- change:
+	case <-w.roleQueue.stop:
+		w.dropRoleChange(change)
+	}
+}
+
+func (w *Manager) dropRoleChange(change roleChange) {
+	w.roleQueue.failed.Add(1)
+
+	log.Error().
+		Str("role_id", change.reaction.RoleID).
+		Str("role", change.reaction.Role).
+		Str("user_id", change.userID).
+		Bool("add", change.add).
+		Msg("discord_bot.welcome.role_change_dropped")
+}
+
+// processRoleChange applies the role change and schedules a retry when Discord may accept it later.
+// A role change superseded by a newer one for the same user and role is dropped, a retry cannot undo a newer change.
+func (w *Manager) processRoleChange(change roleChange) {
+	if w.roleQueue.isSuperseded(change) {
+		log.Info().
+			Str("role_id", change.reaction.RoleID).
+			Str("role", change.reaction.Role).
+			Str("user_id", change.userID).
+			Bool("add", change.add).
+			Int("attempt", change.attempt).
+			Msg("discord_bot.welcome.role_change_superseded")
+
+		return
+	}
+
+	err := w.applyRoleChange(change)
+	if err == nil {
+		w.roleQueue.applied.Add(1)
+		w.roleQueue.done(change)
+
+		return
+	}
+
+	delay, canRetry := roleRetryDelay(err, change.attempt, w.roleRetryDelay)
+	if !canRetry || change.attempt >= w.roleRetries {
+		metrics := w.RoleQueueMetrics()
+
+		log.Error().Err(err).
+			Str("role_id", change.reaction.RoleID).
+			Str("role", change.reaction.Role).
+			Str("user_id", change.userID).
+			Bool("add", change.add).
+			Int("attempts", change.attempt+1).
+			Int("queue_depth", metrics.Depth).
+			Int64("count_failed", metrics.Failed+1).
+			Msg("discord_bot.welcome.role_change_failed")
+
+		w.auditRoleChangeFailed(change, err)
+
+		w.roleQueue.failed.Add(1)
+		w.roleQueue.done(change)
+
+		return
+	}
+
+	select {
+	case <-w.roleQueue.stop:
+		w.dropRoleChange(change)
+
+		return
+	default:
+	}
+
+	w.roleQueue.retried.Add(1)
+	w.roleQueue.retrying.Add(1)
+
+	log.Warn().
+		Str("role_id", change.reaction.RoleID).
+		Str("role", change.reaction.Role).
+		Str("user_id", change.userID).
+		Bool("add", change.add).
+		Int("attempt", change.attempt+1).
+		Str("retry_delay", delay.String()).
+		Msg("discord_bot.welcome.role_change_retrying")
+
+	change.attempt++
+
+	time.AfterFunc(delay, func() {
+		w.roleQueue.retrying.Add(-1)
+		w.enqueueRoleChange(change)
+	})
+}
+
+// roleRetryDelay returns the delay before the next attempt, and false when the error will not change by retrying.
+// Delay of rate limits comes from Discord, otherwise delay is doubled at each attempt.
+func roleRetryDelay(err error, attempt int, baseDelay time.Duration) (time.Duration, bool) {
+	backoff := min(baseDelay<<attempt, maxRoleRetryDelay)
+
+	var rateLimitError *discordgo.RateLimitError
+	if errors.As(err, &rateLimitError) && rateLimitError.TooManyRequests != nil {
+		return max(rateLimitError.RetryAfter, backoff), true
+	}
+
+	var restError *discordgo.RESTError
+	if !errors.As(err, &restError) || restError.Response == nil {
+		return backoff, true
+	}
+
+	switch {
+	case restError.Response.StatusCode == http.StatusTooManyRequests:
+		retryAfter, errParse := strconv.ParseFloat(restError.Response.Header.Get("Retry-After"), 64)
+		if errParse == nil {
+			return max(time.Duration(retryAfter*float64(time.Second)), backoff), true
+		}
+
+		return backoff, true
+	case restError.Response.StatusCode >= http.StatusInternalServerError:
+		return backoff, true
+	default:
+		return 0, false
+	}
+}
+
+func (w *Manager) startRoleQueue() {
+	if w.roleQueueSize <= 0 || w.roleQueue != nil {
+		return
+	}
+
+	log.Info().
+		Int("role_queue_size", w.roleQueueSize).
+		Int("role_retries", w.roleRetries).
+		Str("role_retry_delay", w.roleRetryDelay.String()).
+		Str("role_queue_metrics_interval", w.roleQueueMetricsInterval.String()).
+		Msg("discord_bot.welcome.starting_role_queue")
+
+	w.roleQueue = &roleQueue{
+		changes:  make(chan roleChange, w.roleQueueSize),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		versions: map[roleChangeKey]uint64{},
+	}
+
+	go func(queue *roleQueue) {
+		defer close(queue.stopped)
+
+		ticker := time.NewTicker(w.roleQueueMetricsInterval)
+		defer ticker.Stop()
+
+		metricsLogged := RoleQueueMetrics{}
+
+		for {
+			select {
+			case change := <-queue.changes:
+				w.processRoleChange(change)
+			case <-ticker.C:
+				metricsLogged = w.logRoleQueueMetrics(metricsLogged)
+			case <-queue.stop:
+				for {
+					select {
+					case change := <-queue.changes:
+						w.processRoleChange(change)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}(w.roleQueue)
+}
+
+// logRoleQueueMetrics logs metrics of the role queue while the bot runs, nothing is logged when they did not change since metricsLogged.
+func (w *Manager) logRoleQueueMetrics(metricsLogged RoleQueueMetrics) RoleQueueMetrics {
+	metrics := w.RoleQueueMetrics()
+	if metrics == metricsLogged {
+		return metricsLogged
+	}
+
+	log.Info().
+		Int("queue_depth", metrics.Depth).
+		Int64("count_retrying", metrics.Retrying).
+		Int64("count_applied", metrics.Applied).
+		Int64("count_retried", metrics.Retried).
+		Int64("count_failed", metrics.Failed).
+		Msg("discord_bot.welcome.role_queue_metrics")
+
+	return metrics
+}
+
+// stopRoleQueue applies role changes already queued before stopping, retries waiting for their delay are dropped.
+func (w *Manager) stopRoleQueue() {
+	if w.roleQueue == nil {
+		return
+	}
+
+	select {
+	case <-w.roleQueue.stop:
+		return
+	default:
+	}
+
+	log.Info().
+		Msg("discord_bot.welcome.stopping_role_queue")
+
+	close(w.roleQueue.stop)
+	<-w.roleQueue.stopped
+
+	metrics := w.RoleQueueMetrics()
+
+	log.Info().
+		Int("queue_depth", metrics.Depth).
+		Int64("count_retrying", metrics.Retrying).
+		Int64("count_applied", metrics.Applied).
+		Int64("count_retried", metrics.Retried).
+		Int64("count_failed", metrics.Failed).
+		Msg("discord_bot.welcome.role_queue_stopped")
+}
@@ -0,0 +1,480 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWelcomeManager_ErrorRoleQueue(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	t.Run("should return nil because role_retries is negative", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:       "my-channel",
+			Messages:      []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			RoleQueueSize: 100,
+			RoleRetries:   -1,
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","role_retries":-1,"message":"discord_bot.welcome.configuration_invalid_role_retries"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should return nil because role_retry_delay is invalid", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:        "my-channel",
+			Messages:       []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			RoleQueueSize:  100,
+			RoleRetryDelay: "soon",
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","role_retry_delay":"soon","help":"Accepted values are positive durations like '500ms' or '1s'","message":"discord_bot.welcome.configuration_invalid_role_retry_delay"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should return nil because role_queue_metrics_interval is invalid", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:                  "my-channel",
+			Messages:                 []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			RoleQueueSize:            100,
+			RoleQueueMetricsInterval: "0s",
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","role_queue_metrics_interval":"0s","help":"Accepted values are positive durations like '1m' or '1h'","message":"discord_bot.welcome.configuration_invalid_role_queue_metrics_interval"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+}
+
+//nolint:funlen
+func TestHandlers_OnMessageReactionAdd_RoleQueue(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}},
+			{User: &discordgo.User{ID: "user-id-456"}},
+			{User: &discordgo.User{ID: "user-id-789"}},
+		},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:        "my-channel",
+		Messages:       []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+		RoleQueueSize:  10,
+		RoleRetries:    2,
+		RoleRetryDelay: "1ms",
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	session.Client = createClient(t,
+		[]*http.Response{
+			createJSONResponse(t, []*discordgo.Message{
+				{ID: "123", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+			}),
+			createJSONResponse(t, []discordgo.User{}),
+		},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/my-emoji-1:emoji-123?limit=100"},
+		},
+	)
+
+	err = welcomeManager.Run()
+	require.NoError(t, err)
+
+	t.Run("should retry role change after a server error", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{createErrorResponse(t), createEmptyResponse(t)},
+			[]requestTest{
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-123"},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-123"},
+			},
+		)
+
+		welcomeManager.OnMessageReactionAdd(nil, &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{
+				ChannelID: "channel-123",
+				UserID:    "user-id-456",
+				MessageID: "123",
+				Emoji:     discordgo.Emoji{Name: "my-emoji-1"},
+			},
+		})
+
+		require.Eventually(t, func() bool { return welcomeManager.RoleQueueMetrics().Applied == 1 }, time.Second, time.Millisecond)
+		require.Equal(t, welcome.RoleQueueMetrics{Depth: 0, Retrying: 0, Applied: 1, Retried: 1, Failed: 0}, welcomeManager.RoleQueueMetrics())
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"warn","role_id":"role-123","role":"my role 1","user_id":"user-id-456","add":true,"attempt":1,"retry_delay":"1ms","message":"discord_bot.welcome.role_change_retrying"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","channel_id":"channel-123","message_id":"123","user_id":"user-id-456","message":"discord_bot.welcome.user_role_added"}`, parts[7])
+		require.Empty(t, parts[8])
+	})
+
+	t.Run("should not retry role change when Discord refuses it", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		response := httptest.NewRecorder().Result()
+		response.Status = "403 Forbidden"
+		response.StatusCode = http.StatusForbidden
+
+		t.Cleanup(func() { response.Body.Close() })
+
+		session.Client = createClient(t,
+			[]*http.Response{response},
+			[]requestTest{
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-789/roles/role-123"},
+			},
+		)
+
+		welcomeManager.OnMessageReactionAdd(nil, &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{
+				ChannelID: "channel-123",
+				UserID:    "user-id-789",
+				MessageID: "123",
+				Emoji:     discordgo.Emoji{Name: "my-emoji-1"},
+			},
+		})
+
+		require.Eventually(t, func() bool { return welcomeManager.RoleQueueMetrics().Failed == 1 }, time.Second, time.Millisecond)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","error":"HTTP 403 Forbidden, ","role_id":"role-123","role":"my role 1","user_id":"user-id-789","add":true,"attempts":1,"queue_depth":0,"count_failed":1,"message":"discord_bot.welcome.role_change_failed"}`, parts[4])
+		require.Empty(t, parts[5])
+	})
+
+	t.Run("should log metrics when stopped", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager.Stop()
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.stopping_role_queue"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","queue_depth":0,"count_retrying":0,"count_applied":1,"count_retried":1,"count_failed":1,"message":"discord_bot.welcome.role_queue_stopped"}`, parts[1])
+		require.Empty(t, parts[2])
+	})
+}
+
+// lockedBuffer is written by the role queue while the test reads it.
+type lockedBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(data []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.buffer.Write(data)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.buffer.String()
+}
+
+func (b *lockedBuffer) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.buffer.Reset()
+}
+
+func TestHandlers_OnMessageReactionRemove_RoleQueue(t *testing.T) {
+	var bufferLogs lockedBuffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}},
+			{User: &discordgo.User{ID: "user-id-456"}},
+		},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:        "my-channel",
+		Messages:       []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+		RoleQueueSize:  10,
+		RoleRetries:    2,
+		RoleRetryDelay: "100ms",
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	session.Client = createClient(t,
+		[]*http.Response{
+			createJSONResponse(t, []*discordgo.Message{
+				{ID: "123", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+			}),
+			createJSONResponse(t, []discordgo.User{}),
+		},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/my-emoji-1:emoji-123?limit=100"},
+		},
+	)
+
+	err = welcomeManager.Run()
+	require.NoError(t, err)
+
+	t.Cleanup(welcomeManager.Stop)
+
+	t.Run("should drop retry of role add when reaction is removed meanwhile", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{createErrorResponse(t)},
+			[]requestTest{
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-123"},
+			},
+		)
+
+		messageReaction := &discordgo.MessageReaction{
+			ChannelID: "channel-123",
+			UserID:    "user-id-456",
+			MessageID: "123",
+			Emoji:     discordgo.Emoji{Name: "my-emoji-1"},
+		}
+
+		welcomeManager.OnMessageReactionAdd(nil, &discordgo.MessageReactionAdd{MessageReaction: messageReaction})
+
+		require.Eventually(t, func() bool {
+			return strings.Contains(bufferLogs.String(), "discord_bot.welcome.role_change_retrying")
+		}, time.Second, time.Millisecond)
+
+		welcomeManager.OnMessageReactionRemove(nil, &discordgo.MessageReactionRemove{MessageReaction: messageReaction})
+
+		require.Eventually(t, func() bool { return welcomeManager.RoleQueueMetrics().Retrying == 0 }, time.Second, time.Millisecond)
+		require.Eventually(t, func() bool {
+			return strings.Contains(bufferLogs.String(), "discord_bot.welcome.role_change_superseded")
+		}, time.Second, time.Millisecond)
+		require.Equal(t, welcome.RoleQueueMetrics{Depth: 0, Retrying: 0, Applied: 0, Retried: 1, Failed: 0}, welcomeManager.RoleQueueMetrics())
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-456","is_member":true,"message":"discord_bot.welcome.user_role_removing_skipped"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-456","add":true,"attempt":1,"message":"discord_bot.welcome.role_change_superseded"}`, parts[8])
+		require.Empty(t, parts[9])
+	})
+}
+
+//nolint:funlen
+func TestHandlers_RoleQueue_Audit(t *testing.T) {
+	log.Logger = zerolog.Nop()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}, {ID: "channel-audit", Name: "my-audit-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}},
+			{User: &discordgo.User{ID: "user-id-456"}},
+			{User: &discordgo.User{ID: "user-id-789"}},
+		},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:        "my-channel",
+		Messages:       []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+		AuditChannel:   "my-audit-channel",
+		RoleQueueSize:  10,
+		RoleRetries:    2,
+		RoleRetryDelay: "1ms",
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	session.Client = createClient(t,
+		[]*http.Response{
+			createJSONResponse(t, []*discordgo.Message{
+				{ID: "123", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+			}),
+			createJSONResponse(t, []discordgo.User{}),
+		},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/my-emoji-1:emoji-123?limit=100"},
+		},
+	)
+
+	err = welcomeManager.Run()
+	require.NoError(t, err)
+
+	t.Cleanup(welcomeManager.Stop)
+
+	t.Run("should only audit role added when a retry succeeds", func(t *testing.T) {
+		session.Client = createClient(t,
+			[]*http.Response{createErrorResponse(t), createEmptyResponse(t), createJSONResponse(t, discordgo.Message{ID: "300"})},
+			[]requestTest{
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-123"},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-123"},
+				{method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-audit/messages", body: `{"embeds":[{"type":"rich","title":"Role added","description":"➕ \u003c@\u0026role-123\u003e to \u003c@user-id-456\u003e","color":3066993}],"tts":false,"components":null,"sticker_ids":null}`},
+			},
+		)
+
+		welcomeManager.OnMessageReactionAdd(nil, &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{ChannelID: "channel-123", UserID: "user-id-456", MessageID: "123", Emoji: discordgo.Emoji{Name: "my-emoji-1"}},
+		})
+
+		require.Eventually(t, func() bool { return welcomeManager.RoleQueueMetrics().Applied == 1 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("should audit role adding failed once when retries are exhausted", func(t *testing.T) {
+		session.Client = createClient(t,
+			[]*http.Response{createErrorResponse(t), createErrorResponse(t), createErrorResponse(t), createJSONResponse(t, discordgo.Message{ID: "301"})},
+			[]requestTest{
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-789/roles/role-123"},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-789/roles/role-123"},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-789/roles/role-123"},
+				{method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-audit/messages", body: `{"embeds":[{"type":"rich","title":"Role adding failed","description":"❌ \u003c@\u0026role-123\u003e not added to \u003c@user-id-789\u003e: HTTP 500 Internal Server Error, ","color":15158332}],"tts":false,"components":null,"sticker_ids":null}`},
+			},
+		)
+
+		welcomeManager.OnMessageReactionAdd(nil, &discordgo.MessageReactionAdd{
+			MessageReaction: &discordgo.MessageReaction{ChannelID: "channel-123", UserID: "user-id-789", MessageID: "123", Emoji: discordgo.Emoji{Name: "my-emoji-1"}},
+		})
+
+		require.Eventually(t, func() bool { return welcomeManager.RoleQueueMetrics().Failed == 1 }, time.Second, time.Millisecond)
+	})
+}
+
+func TestRun_RoleQueueMetrics(t *testing.T) {
+	var bufferLogs lockedBuffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}},
+			{User: &discordgo.User{ID: "user-id-456"}},
+		},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:                  "my-channel",
+		Messages:                 []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+		RoleQueueSize:            10,
+		RoleQueueMetricsInterval: "1ms",
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	session.Client = createClient(t,
+		[]*http.Response{
+			createJSONResponse(t, []*discordgo.Message{
+				{ID: "123", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+			}),
+			createJSONResponse(t, []discordgo.User{}),
+			createEmptyResponse(t),
+		},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/my-emoji-1:emoji-123?limit=100"},
+			{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-123"},
+		},
+	)
+
+	err = welcomeManager.Run()
+	require.NoError(t, err)
+
+	t.Cleanup(welcomeManager.Stop)
+
+	welcomeManager.OnMessageReactionAdd(nil, &discordgo.MessageReactionAdd{
+		MessageReaction: &discordgo.MessageReaction{ChannelID: "channel-123", UserID: "user-id-456", MessageID: "123", Emoji: discordgo.Emoji{Name: "my-emoji-1"}},
+	})
+
+	metricsLogged := `{"level":"info","queue_depth":0,"count_retrying":0,"count_applied":1,"count_retried":0,"count_failed":0,"message":"discord_bot.welcome.role_queue_metrics"}`
+
+	require.Eventually(t, func() bool {
+		return slices.Contains(strings.Split(bufferLogs.String(), "\n"), metricsLogged)
+	}, time.Second, time.Millisecond)
+
+	// metrics which did not change are not logged again
+	time.Sleep(20 * time.Millisecond)
+
+	require.Equal(t, 1, strings.Count(bufferLogs.String(), "discord_bot.welcome.role_queue_metrics"))
+}
@@ -0,0 +1,269 @@
+package welcome
+
+import (
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+// usersByRole keeps for each role ID the users who reacted to get it.
+type usersByRole map[string]map[string]struct{}
+
+func (u usersByRole) add(roleID string, userID string) {
+	if u[roleID] == nil {
+		u[roleID] = map[string]struct{}{}
+	}
+
+	u[roleID][userID] = struct{}{}
+}
+
+func (u usersByRole) has(roleID string, userID string) bool {
+	_, found := u[roleID][userID]
+
+	return found
+}
+
+func (w *Manager) startReconciliation() {
+	if w.reconcileInterval <= 0 || w.stopReconciliation != nil {
+		return
+	}
+
+	log.Info().
+		Str("reconcile_interval", w.reconcileInterval.String()).
+		Msg("discord_bot.welcome.starting_reconciliation")
+
+	w.stopReconciliation = make(chan struct{})
+	w.reconciliationStopped = make(chan struct{})
+
+	go func(stopReconciliation chan struct{}, reconciliationStopped chan struct{}) {
+		defer close(reconciliationStopped)
+
+		ticker := time.NewTicker(w.reconcileInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopReconciliation:
+				return
+			case <-ticker.C:
+				w.Reconcile()
+			}
+		}
+	}(w.stopReconciliation, w.reconciliationStopped)
+}
+
+// Stop stops the periodic reconciliation, a reconciliation in progress is finished before.
+// Role changes already queued are applied, then role changes kept for the audit channel are posted before stopping.
+func (w *Manager) Stop() {
+	w.stopReconciliationLoop()
+	w.stopRoleQueue()
+	w.stopAuditBatch()
+}
+
+func (w *Manager) stopReconciliationLoop() {
+	if w.stopReconciliation == nil {
+		return
+	}
+
+	log.Info().
+		Msg("discord_bot.welcome.stopping_reconciliation")
+
+	close(w.stopReconciliation)
+	<-w.reconciliationStopped
+
+	w.stopReconciliation = nil
+	w.reconciliationStopped = nil
+
+	log.Info().
+		Msg("discord_bot.welcome.reconciliation_stopped")
+}
+
+// Reconcile is public for tests, never call it directly
+func (w *Manager) Reconcile() {
+	log.Info().
+		Msg("discord_bot.welcome.reconciling_roles")
+
+	// members are taken before reactions, a member reacting meanwhile gets the role after this snapshot and keeps it
+	members := w.guildMembers()
+
+	choices := exclusiveChoices{}
+	usersReacted := usersByRole{}
+
+	for _, message := range w.messages {
+		if message.ID == "" {
+			continue
+		}
+
+		err := w.updateUserRoleBelongMessage(message, choices, usersReacted)
+		if err != nil {
+			log.Error().Err(err).
+				Str("message_title", message.Title).
+				Msg("discord_bot.welcome.roles_reconciling_failed")
+
+			return
+		}
+	}
+
+	w.removeRolesNotChosenInExclusiveGroups(choices)
+
+	w.removeRolesWithoutReaction(usersReacted, members)
+
+	log.Info().
+		Msg("discord_bot.welcome.roles_reconciled")
+
+	w.logDryRunSummary()
+}
+
+// roleWithoutReaction is a role held by a member who has not reacted to get it.
+type roleWithoutReaction struct {
+	userID   string
+	reaction Reaction
+}
+
+// removeRolesWithoutReaction removes roles of reactions from members who have not reacted.
+// Nothing is removed above maxRolesRemoved, a missing page of reactions would otherwise strip roles of everyone.
+func (w *Manager) removeRolesWithoutReaction(usersReacted usersByRole, members []discordgo.Member) {
+	rolesToRemove := w.rolesWithoutReaction(usersReacted, members)
+	if len(rolesToRemove) > w.maxRolesRemoved {
+		log.Error().
+			Int("count_roles", len(rolesToRemove)).
+			Int("max_roles_removed", w.maxRolesRemoved).
+			Msg("discord_bot.welcome.roles_without_reaction_above_limit")
+
+		return
+	}
+
+	for _, roleToRemove := range rolesToRemove {
+		if w.dryRunRemoveRoles {
+			log.Info().
+				Str("role_id", roleToRemove.reaction.RoleID).
+				Str("role", roleToRemove.reaction.Role).
+				Str("user_id", roleToRemove.userID).
+				Msg("discord_bot.welcome.role_without_reaction_removing_dry_run")
+
+			continue
+		}
+
+		log.Info().
+			Str("role_id", roleToRemove.reaction.RoleID).
+			Str("role", roleToRemove.reaction.Role).
+			Str("user_id", roleToRemove.userID).
+			Msg("discord_bot.welcome.role_without_reaction_removing")
+
+		if w.skipInDryRun(changeRoleRemove) {
+			continue
+		}
+
+		err := w.discordSession.GuildMemberRoleRemove(w.guildID, roleToRemove.userID, roleToRemove.reaction.RoleID)
+		if err != nil {
+			log.Error().Err(err).
+				Str("role_id", roleToRemove.reaction.RoleID).
+				Str("role", roleToRemove.reaction.Role).
+				Str("user_id", roleToRemove.userID).
+				Msg("discord_bot.welcome.role_without_reaction_removing_failed")
+
+			w.audit(auditRoleRemovingFailed, roleToRemove.userID, roleToRemove.reaction.RoleID, err)
+
+			continue
+		}
+
+		w.audit(auditRoleRemoved, roleToRemove.userID, roleToRemove.reaction.RoleID, nil)
+
+		log.Info().
+			Str("role_id", roleToRemove.reaction.RoleID).
+			Str("role", roleToRemove.reaction.Role).
+			Str("user_id", roleToRemove.userID).
+			Msg("discord_bot.welcome.role_without_reaction_removed")
+	}
+}
+
+// rolesWithoutReaction returns roles held by members without reaction to get them.
+// Members are taken before fetching reactions, roles given meanwhile by a reaction are not in members.
+func (w *Manager) rolesWithoutReaction(usersReacted usersByRole, members []discordgo.Member) []roleWithoutReaction {
+	reactions := w.reactionsGivingRoleOnlyByReaction()
+	if len(reactions) == 0 {
+		return nil
+	}
+
+	var rolesToRemove []roleWithoutReaction
+
+	for _, member := range members {
+		if member.User == nil || w.isUserBot(member.User.ID) {
+			continue
+		}
+
+		for _, reaction := range reactions {
+			if !slices.Contains(member.Roles, reaction.RoleID) || usersReacted.has(reaction.RoleID, member.User.ID) {
+				continue
+			}
+
+			rolesToRemove = append(rolesToRemove, roleWithoutReaction{userID: member.User.ID, reaction: reaction})
+		}
+	}
+
+	return rolesToRemove
+}
+
+// reactionsGivingRoleOnlyByReaction returns reactions of published messages, one per role.
+// Roles also given by buttons or select menu are excluded because members get them without reaction.
+func (w *Manager) reactionsGivingRoleOnlyByReaction() []Reaction {
+	var (
+		reactions       []Reaction
+		rolesComponents []string
+	)
+
+	for _, message := range w.messages {
+		if !message.usesComponents() {
+			continue
+		}
+
+		for _, reaction := range message.Reactions {
+			rolesComponents = append(rolesComponents, reaction.RoleID)
+		}
+	}
+
+	for _, message := range w.messages {
+		if message.ID == "" || message.usesComponents() {
+			continue
+		}
+
+		for _, reaction := range message.Reactions {
+			if slices.Contains(rolesComponents, reaction.RoleID) {
+				continue
+			}
+
+			if slices.ContainsFunc(reactions, func(reactionAdded Reaction) bool { return reactionAdded.RoleID == reaction.RoleID }) {
+				continue
+			}
+
+			reactions = append(reactions, reaction)
+		}
+	}
+
+	return reactions
+}
+
+// guildMembers returns a copy of members from state, state can be updated by Discord events meanwhile.
+func (w *Manager) guildMembers() []discordgo.Member {
+	guild, err := w.discordSession.State.Guild(w.guildID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("guild_id", w.guildID).
+			Msg("discord_bot.welcome.guild_members_fetching_failed")
+
+		return nil
+	}
+
+	w.discordSession.State.RLock()
+	defer w.discordSession.State.RUnlock()
+
+	members := make([]discordgo.Member, 0, len(guild.Members))
+
+	for _, member := range guild.Members {
+		members = append(members, discordgo.Member{User: member.User, Roles: slices.Clone(member.Roles)})
+	}
+
+	return members
+}
@@ -0,0 +1,349 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWelcomeManager_ErrorReconcileInterval(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:           "my-channel",
+		Messages:          []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+		ReconcileInterval: "every hour",
+	}, guildName, session)
+	require.Nil(t, welcomeManager)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+	require.JSONEq(t, `{"level":"error","reconcile_interval":"every hour","help":"Accepted values are positive durations like '30m' or '1h'","message":"discord_bot.welcome.configuration_invalid_reconcile_interval"}`, parts[1])
+	require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+	require.Empty(t, parts[3])
+}
+
+//nolint:funlen
+func TestReconcile(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-123", "role-bot"}},
+			{User: &discordgo.User{ID: "user-id-456"}, Roles: []string{"role-123"}},
+			{User: &discordgo.User{ID: "user-id-789"}, Roles: []string{"role-123"}},
+			{User: &discordgo.User{ID: "user-id-999"}, Roles: []string{}},
+		},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:           "my-channel",
+		Messages:          []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+		ReconcileInterval: "1h",
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	t.Run("should start reconciliation after messages are added and stop it", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{ID: "200", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+				}),
+				createJSONResponse(t, []discordgo.User{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		welcomeManager.Stop()
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","reconcile_interval":"1h0m0s","message":"discord_bot.welcome.starting_reconciliation"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.stopping_reconciliation"}`, parts[8])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.reconciliation_stopped"}`, parts[9])
+		require.Empty(t, parts[10])
+	})
+
+	t.Run("should add missing roles and remove roles of members without reaction", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []discordgo.User{{ID: "user-id-456"}, {ID: "user-id-999"}}),
+				createJSONResponse(t, []discordgo.User{}),
+				createEmptyResponse(t),
+				createEmptyResponse(t),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?after=user-id-999&limit=100"},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-999/roles/role-123"},
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-789/roles/role-123"},
+			},
+		)
+
+		welcomeManager.Reconcile()
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.reconciling_roles"}`, parts[0])
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[1])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-999","username":"","message":"discord_bot.welcome.adding_user_role_adding"}`, parts[2])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-789","message":"discord_bot.welcome.role_without_reaction_removing"}`, parts[3])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-789","message":"discord_bot.welcome.role_without_reaction_removed"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.roles_reconciled"}`, parts[5])
+		require.Empty(t, parts[6])
+	})
+
+	t.Run("should not remove roles when reactions cannot be fetched", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{createErrorResponse(t)},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+			},
+		)
+
+		welcomeManager.Reconcile()
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.reconciling_roles"}`, parts[0])
+		require.JSONEq(t, `{"level":"error","error":"HTTP 500 Internal Server Error, ","message_title":"my title 1","message":"discord_bot.welcome.roles_reconciling_failed"}`, parts[3])
+		require.Empty(t, parts[4])
+	})
+}
+
+func TestNewWelcomeManager_ErrorMaxRolesRemoved(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:         "my-channel",
+		Messages:        []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+		MaxRolesRemoved: -1,
+	}, guildName, session)
+	require.Nil(t, welcomeManager)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+	require.JSONEq(t, `{"level":"error","max_roles_removed":-1,"message":"discord_bot.welcome.configuration_invalid_max_roles_removed"}`, parts[1])
+	require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+	require.Empty(t, parts[3])
+}
+
+//nolint:funlen
+func TestRun_RemoveRolesWithoutReaction(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "user-id-456"}, Roles: []string{"role-123"}},
+			{User: &discordgo.User{ID: "user-id-789"}, Roles: []string{"role-123"}},
+			{User: &discordgo.User{ID: "user-id-999"}, Roles: []string{"role-123"}},
+		},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	createClientWithReactions := func(t *testing.T, responses []*http.Response, requests []requestTest) *http.Client {
+		t.Helper()
+
+		return createClient(t,
+			append([]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{ID: "200", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+				}),
+				createJSONResponse(t, []discordgo.User{{ID: "user-id-456"}}),
+				createJSONResponse(t, []discordgo.User{}),
+			}, responses...),
+			append([]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?after=user-id-456&limit=100"},
+			}, requests...),
+		)
+	}
+
+	t.Run("should remove roles of members without reaction", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:                    "my-channel",
+			Messages:                   []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			RemoveRolesWithoutReaction: true,
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClientWithReactions(t,
+			[]*http.Response{createEmptyResponse(t), createErrorResponse(t)},
+			[]requestTest{
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-789/roles/role-123"},
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-999/roles/role-123"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-789","message":"discord_bot.welcome.role_without_reaction_removing"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-789","message":"discord_bot.welcome.role_without_reaction_removed"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-999","message":"discord_bot.welcome.role_without_reaction_removing"}`, parts[8])
+		require.JSONEq(t, `{"level":"error","error":"HTTP 500 Internal Server Error, ","role_id":"role-123","role":"my role 1","user_id":"user-id-999","message":"discord_bot.welcome.role_without_reaction_removing_failed"}`, parts[9])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[10])
+		require.Empty(t, parts[11])
+	})
+
+	t.Run("should not remove roles above max_roles_removed", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:                    "my-channel",
+			Messages:                   []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			RemoveRolesWithoutReaction: true,
+			MaxRolesRemoved:            1,
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClientWithReactions(t, nil, nil)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","count_roles":2,"max_roles_removed":1,"message":"discord_bot.welcome.roles_without_reaction_above_limit"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[7])
+		require.Empty(t, parts[8])
+	})
+
+	t.Run("should only log roles to remove in dry run", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:                    "my-channel",
+			Messages:                   []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			RemoveRolesWithoutReaction: true,
+			DryRunRemoveRoles:          true,
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClientWithReactions(t, nil, nil)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-789","message":"discord_bot.welcome.role_without_reaction_removing_dry_run"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-999","message":"discord_bot.welcome.role_without_reaction_removing_dry_run"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[8])
+		require.Empty(t, parts[9])
+	})
+
+	t.Run("should keep role given to a member reacting while reactions are fetched", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:                    "my-channel",
+			Messages:                   []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			RemoveRolesWithoutReaction: true,
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClientWithReactions(t,
+			[]*http.Response{createEmptyResponse(t), createEmptyResponse(t)},
+			[]requestTest{
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-789/roles/role-123"},
+				{method: "DELETE", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-999/roles/role-123"},
+			},
+		)
+		session.Client.Transport = &memberReactingRoundTripper{next: session.Client.Transport, session: session}
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-789","message":"discord_bot.welcome.role_without_reaction_removed"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-999","message":"discord_bot.welcome.role_without_reaction_removed"}`, parts[9])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[10])
+		require.Empty(t, parts[11])
+
+		err = session.State.MemberRemove(&discordgo.Member{GuildID: "guild-123", User: &discordgo.User{ID: "user-id-111"}})
+		require.NoError(t, err)
+	})
+}
+
+// memberReactingRoundTripper gives the role to a member as the reaction handler would, while reactions are fetched.
+type memberReactingRoundTripper struct {
+	next    http.RoundTripper
+	session *discordgo.Session
+}
+
+func (rt *memberReactingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.Contains(req.URL.Path, "/reactions/") {
+		_ = rt.session.State.MemberAdd(&discordgo.Member{GuildID: "guild-123", User: &discordgo.User{ID: "user-id-111"}, Roles: []string{"role-123"}})
+	}
+
+	return rt.next.RoundTrip(req)
+}
@@ -0,0 +1,88 @@
+package welcome
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	minLengthSnowflake = 17
+	maxLengthSnowflake = 20
+)
+
+// isSnowflake returns true when value looks like a Discord ID.
+func isSnowflake(value string) bool {
+	if len(value) < minLengthSnowflake || len(value) > maxLengthSnowflake {
+		return false
+	}
+
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// findByIDOrName returns items with explicitID when set, otherwise items with value as ID, then items with value as name.
+// Several items are returned when value matches names of several items, configuration is ambiguous in that case.
+func findByIDOrName[T any](items []T, idOf func(T) string, nameOf func(T) string, explicitID string, value string) []T {
+	if explicitID != "" {
+		return filterItems(items, func(item T) bool { return idOf(item) == explicitID })
+	}
+
+	if isSnowflake(value) {
+		itemsFound := filterItems(items, func(item T) bool { return idOf(item) == value })
+		if len(itemsFound) > 0 {
+			return itemsFound
+		}
+	}
+
+	if value == "" {
+		return nil
+	}
+
+	return filterItems(items, func(item T) bool { return nameOf(item) == value })
+}
+
+func filterItems[T any](items []T, keep func(T) bool) []T {
+	var itemsFound []T
+
+	for _, item := range items {
+		if keep(item) {
+			itemsFound = append(itemsFound, item)
+		}
+	}
+
+	return itemsFound
+}
+
+func findChannels(guild *discordgo.Guild, channelID string, channel string) []*discordgo.Channel {
+	return findByIDOrName(
+		guild.Channels,
+		func(item *discordgo.Channel) string { return item.ID },
+		func(item *discordgo.Channel) string { return item.Name },
+		channelID,
+		channel,
+	)
+}
+
+func findRoles(guild *discordgo.Guild, roleID string, role string) []*discordgo.Role {
+	return findByIDOrName(
+		guild.Roles,
+		func(item *discordgo.Role) string { return item.ID },
+		func(item *discordgo.Role) string { return item.Name },
+		roleID,
+		role,
+	)
+}
+
+func findEmojis(guild *discordgo.Guild, emojiID string, emoji string) []*discordgo.Emoji {
+	return findByIDOrName(
+		guild.Emojis,
+		func(item *discordgo.Emoji) string { return item.ID },
+		func(item *discordgo.Emoji) string { return item.Name },
+		emojiID,
+		emoji,
+	)
+}
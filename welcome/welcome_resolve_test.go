@@ -0,0 +1,135 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWelcomeManager_ResolveByID(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	session.State.Guilds = append(session.State.Guilds, &discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "100000000000000001", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "300000000000000001", Name: "my-emoji-1"}},
+		Roles: []*discordgo.Role{
+			{ID: "200000000000000001", Name: "my role 1"},
+			{ID: "200000000000000002", Name: "my role 1"},
+			{ID: "200000000000000003", Name: "renamed role"},
+		},
+	})
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "100000000000000001",
+		Messages: []welcome.Message{
+			{
+				Title:  "my title 1",
+				Emoji:  "300000000000000001",
+				RoleID: "200000000000000002",
+				Reactions: []welcome.Reaction{
+					{Emoji: "✅", Role: "200000000000000003"},
+				},
+			},
+		},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+	require.JSONEq(t, `{"level":"info","guild_id":"guild-123","guild":"guild-name","message":"discord_bot.welcome.set_guild_id"}`, parts[1])
+	require.JSONEq(t, `{"level":"info","channel_id":"100000000000000001","channel":"my-channel","message":"discord_bot.welcome.set_channel_id"}`, parts[2])
+	require.JSONEq(t, `{"level":"info","message index":0,"reaction index":0,"role_id":"200000000000000002","role":"my role 1","message":"discord_bot.welcome.set_role_id"}`, parts[3])
+	require.JSONEq(t, `{"level":"info","message index":0,"reaction index":1,"role_id":"200000000000000003","role":"renamed role","message":"discord_bot.welcome.set_role_id"}`, parts[4])
+	require.JSONEq(t, `{"level":"info","message index":0,"reaction index":0,"emoji_id":"300000000000000001","emoji":"my-emoji-1","message":"discord_bot.welcome.set_emoji_id"}`, parts[5])
+	require.JSONEq(t, `{"level":"info","message index":0,"reaction index":1,"emoji":"✅","message":"discord_bot.welcome.set_unicode_emoji"}`, parts[6])
+	require.JSONEq(t, `{"level":"warn","guild":"guild-name","message":"discord_bot.welcome.permissions_check_skipped"}`, parts[7])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.configuration_validated"}`, parts[8])
+	require.Empty(t, parts[9])
+}
+
+//nolint:funlen
+func TestNewWelcomeManager_ErrorResolve(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	session.State.Guilds = append(session.State.Guilds, &discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}, {ID: "channel-456", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}, {ID: "emoji-456", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-456", Name: "my role 1"}},
+	})
+
+	t.Run("should return nil because channel name is ambiguous", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", EmojiID: "emoji-123", RoleID: "role-123"}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","channel":"my-channel","count_channels":2,"help":"Several channels have this name, use channel_id instead","message":"discord_bot.welcome.configuration_channel_ambiguous"}`, parts[4])
+		require.JSONEq(t, `{"level":"error","step":2,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[5])
+		require.Empty(t, parts[6])
+	})
+
+	t.Run("should return nil because channel_id is not found", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			ChannelID: "channel-789",
+			Messages:  []welcome.Message{{Title: "my title 1", EmojiID: "emoji-123", RoleID: "role-123"}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","channel_id":"channel-789","message":"discord_bot.welcome.configuration_channel_id_missed"}`, parts[4])
+	})
+
+	t.Run("should return nil because emoji name is ambiguous", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			ChannelID: "channel-123",
+			Messages:  []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", RoleID: "role-123"}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","message index":0,"reaction index":0,"emoji":"my-emoji-1","count_emojis":2,"help":"Several emojis have this name, use emoji_id instead","message":"discord_bot.welcome.configuration_emoji_ambiguous"}`, parts[4])
+	})
+
+	t.Run("should return nil because role name is ambiguous", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			ChannelID: "channel-123",
+			Messages:  []welcome.Message{{Title: "my title 1", EmojiID: "emoji-123", Role: "my role 1"}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","message index":0,"reaction index":0,"role":"my role 1","count_roles":2,"help":"Several roles have this name, use role_id instead","message":"discord_bot.welcome.configuration_role_ambiguous"}`, parts[4])
+	})
+}
@@ -12,6 +12,8 @@ import (
 
 // Run do the main task of Welcome.
 func (w *Manager) Run() error {
+	w.startRoleQueue()
+
 	log.Info().
 		Msg("discord_bot.welcome.add_handler_on_message_reaction_add")
 
@@ -22,6 +24,29 @@ func (w *Manager) Run() error {
 
 	w.discordSession.AddHandler(w.OnMessageReactionRemove)
 
+	if w.greeting != nil {
+		log.Info().
+			Msg("discord_bot.welcome.add_handler_on_guild_member_add")
+
+		w.discordSession.AddHandler(w.OnGuildMemberAdd)
+	}
+
+	if slices.ContainsFunc(w.messages, func(message Message) bool { return message.CanPurgeReactions && !message.usesComponents() }) {
+		log.Info().
+			Msg("discord_bot.welcome.add_handler_on_guild_member_remove")
+
+		w.discordSession.AddHandler(w.OnGuildMemberRemove)
+	}
+
+	if slices.ContainsFunc(w.messages, Message.usesComponents) {
+		log.Info().
+			Msg("discord_bot.welcome.add_handler_on_interaction_create")
+
+		w.discordSession.AddHandler(w.OnInteractionCreate)
+	}
+
+	w.startAuditBatch()
+
 	log.Info().
 		Msg("discord_bot.welcome.adding_messages")
 
@@ -30,121 +55,335 @@ func (w *Manager) Run() error {
 		log.Error().Err(err).
 			Msg("discord_bot.welcome.messages_adding_failed")
 
+		w.stopAuditBatch()
+
 		return err
 	}
 
 	log.Info().
 		Msg("discord_bot.welcome.messages_added")
 
+	w.logDryRunSummary()
+
+	w.startReconciliation()
+
 	return nil
 }
 
-//nolint:funlen,cyclop
+// messagesTreated keeps indexes of configured messages and IDs of Discord messages already matched.
+type messagesTreated struct {
+	idxsMessage []int
+	messageIDs  []string
+}
+
+func (t *messagesTreated) add(idxMessage int, messageID string) {
+	t.idxsMessage = append(t.idxsMessage, idxMessage)
+	t.messageIDs = append(t.messageIDs, messageID)
+}
+
+func (t *messagesTreated) hasMessage(idxMessage int) bool {
+	return slices.Contains(t.idxsMessage, idxMessage)
+}
+
+func (t *messagesTreated) hasMessageID(messageID string) bool {
+	return slices.Contains(t.messageIDs, messageID)
+}
+
+//nolint:cyclop
 func (w *Manager) addMessagesToChannel() error {
-	log.Info().
-		Str("channel_id", w.channelID).
-		Str("channel", w.channelName).
-		Msg("discord_bot.welcome.fetching_messages")
+	treated := &messagesTreated{}
 
-	messages, err := w.discordSession.ChannelMessages(w.channelID, limitChannelMessages, "", "", "")
-	if err != nil {
-		log.Error().Err(err).
-			Str("channel_id", w.channelID).
-			Str("channel", w.channelName).
-			Msg("discord_bot.welcome.messages_fetching_failed")
+	if w.stateFilename != "" {
+		err := w.findMessagesFromState(treated)
+		if err != nil {
+			return err
+		}
+	}
 
-		return fmt.Errorf("%w", err)
+	for _, channelID := range w.channelIDs() {
+		if !w.isChannelToScan(channelID, treated) {
+			continue
+		}
+
+		err := w.findMessagesFromChannel(channelID, w.channelName(channelID), treated)
+		if err != nil {
+			return err
+		}
 	}
 
-	log.Info().
-		Str("channel_id", w.channelID).
-		Str("channel", w.channelName).
-		Msg("discord_bot.welcome.messages_fetched")
+	// roles are updated following configuration order, in exclusive groups the first reaction found is kept
+	idxsMessageTreated := slices.Sorted(slices.Values(treated.idxsMessage))
 
-	var idxsMessageTreated []int
+	// members are taken before reactions, a member reacting meanwhile gets the role after this snapshot and keeps it
+	var members []discordgo.Member
+	if w.removeRolesAtStartup {
+		members = w.guildMembers()
+	}
 
-	for _, message := range messages {
-		if message.Author.ID != w.discordSession.State.User.ID {
-			continue
+	choices := exclusiveChoices{}
+	usersReacted := usersByRole{}
+
+	for _, idxMessage := range idxsMessageTreated {
+		err := w.updateUserRoleBelongMessage(w.messages[idxMessage], choices, usersReacted)
+		if err != nil {
+			log.Error().Err(err).
+				Str("message_title", w.messages[idxMessage].Title).
+				Msg("discord_bot.welcome.role_updating_failed")
+
+			return err
 		}
+	}
+
+	w.removeRolesNotChosenInExclusiveGroups(choices)
 
-		if len(message.Embeds) == 0 {
+	// only messages found have their reactions fetched, messages added below have no reaction yet
+	if w.removeRolesAtStartup {
+		w.removeRolesWithoutReaction(usersReacted, members)
+	}
+
+	for idxMessage := range w.messages {
+		if treated.hasMessage(idxMessage) {
 			continue
 		}
 
-		for idxMessage := range w.messages {
-			if w.isSameMessageAgainstConfig(message.Embeds[0], w.messages[idxMessage]) {
-				w.messages[idxMessage].ID = message.ID
+		log.Info().
+			Str("message_title", w.messages[idxMessage].Title).
+			Msg("discord_bot.welcome.adding_missed_messages")
 
-				err := w.updateUserRoleBelongMessage(w.messages[idxMessage])
-				if err != nil {
-					log.Error().Err(err).
-						Str("message_title", w.messages[idxMessage].Title).
-						Msg("discord_bot.welcome.role_updating_failed")
+		messageID, err := w.addMessage(w.messages[idxMessage])
+		if err != nil {
+			log.Error().Err(err).
+				Str("message_title", w.messages[idxMessage].Title).
+				Msg("discord_bot.welcome.messages_missed_adding_failed")
 
-					return err
-				}
+			return err
+		}
+
+		w.messages[idxMessage].ID = messageID
+
+		log.Info().
+			Str("message_title", w.messages[idxMessage].Title).
+			Msg("discord_bot.welcome.missed_messages_added")
+
+		treated.add(idxMessage, messageID)
+	}
+
+	if w.stateFilename != "" && !w.dryRun {
+		w.saveState()
+	}
+
+	return nil
+}
+
+func (w *Manager) channelName(channelID string) string {
+	for _, message := range w.messages {
+		if message.ChannelID == channelID {
+			return message.Channel
+		}
+	}
+
+	return ""
+}
 
-				idxsMessageTreated = append(idxsMessageTreated, idxMessage)
+// isChannelToScan returns true when messages of channel are missing or unknown messages have to be deleted.
+func (w *Manager) isChannelToScan(channelID string, treated *messagesTreated) bool {
+	if w.deleteUnknownMessages {
+		return true
+	}
+
+	for idxMessage := range w.messages {
+		if w.messages[idxMessage].ChannelID == channelID && !treated.hasMessage(idxMessage) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//nolint:funlen,cyclop
+func (w *Manager) findMessagesFromChannel(channelID string, channelName string, treated *messagesTreated) error {
+	messagesFromBot, err := w.fetchMessagesFromChannel(channelID, channelName, treated)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messagesFromBot {
+		for idxMessage := range w.messages {
+			if w.messages[idxMessage].ChannelID != channelID || treated.hasMessage(idxMessage) {
+				continue
+			}
+
+			if w.isSameMessageAgainstConfig(message, w.messages[idxMessage]) {
+				w.messages[idxMessage].ID = message.ID
+
+				treated.add(idxMessage, message.ID)
 
 				break
 			}
 		}
 	}
 
+	// messages changed in configuration are found by their key and edited to keep reactions of users
 	for idxMessage := range w.messages {
-		messageTreated := slices.Contains(idxsMessageTreated, idxMessage)
+		if w.messages[idxMessage].ChannelID != channelID || treated.hasMessage(idxMessage) {
+			continue
+		}
 
-		if !messageTreated {
-			log.Info().
-				Str("message_title", w.messages[idxMessage].Title).
-				Msg("discord_bot.welcome.adding_missed_messages")
+		for _, message := range messagesFromBot {
+			if treated.hasMessageID(message.ID) || !w.isSameKeyAgainstConfig(message.Embeds[0], w.messages[idxMessage]) {
+				continue
+			}
 
-			messageID, err := w.addMessage(w.messages[idxMessage])
+			err = w.editMessage(message, w.messages[idxMessage])
 			if err != nil {
-				log.Error().Err(err).
-					Str("message_title", w.messages[idxMessage].Title).
-					Msg("discord_bot.welcome.messages_missed_adding_failed")
-
 				return err
 			}
 
-			w.messages[idxMessage].ID = messageID
+			w.messages[idxMessage].ID = message.ID
 
-			log.Info().
-				Str("message_title", w.messages[idxMessage].Title).
-				Msg("discord_bot.welcome.missed_messages_added")
+			treated.add(idxMessage, message.ID)
 
-			idxsMessageTreated = append(idxsMessageTreated, idxMessage)
+			break
 		}
 	}
 
+	if w.deleteUnknownMessages {
+		w.deleteMessagesNotInConfig(channelID, channelName, messagesFromBot, treated.messageIDs)
+	}
+
 	return nil
 }
 
-func (w *Manager) isSameMessageAgainstConfig(messageFromDiscord *discordgo.MessageEmbed, messageFromConfig Message) bool {
-	return messageFromDiscord.Title == messageFromConfig.Title &&
-		messageFromDiscord.Description == messageFromConfig.Description &&
-		messageFromDiscord.Color == messageFromConfig.Color
+// fetchMessagesFromChannel pages backwards through channel history and returns messages of the bot not treated.
+// It stops when every message is found, when the beginning of channel is reached or after scanLimitMessages messages.
+//
+//nolint:funlen
+func (w *Manager) fetchMessagesFromChannel(channelID string, channelName string, treated *messagesTreated) ([]*discordgo.Message, error) {
+	log.Info().
+		Str("channel_id", channelID).
+		Str("channel", channelName).
+		Msg("discord_bot.welcome.fetching_messages")
+
+	var (
+		messagesFromBot []*discordgo.Message
+		beforeID        string
+		countScanned    int
+	)
+
+	for {
+		limit := min(limitChannelMessages, w.scanLimitMessages-countScanned)
+
+		messages, err := w.discordSession.ChannelMessages(channelID, limit, beforeID, "", "")
+		if err != nil {
+			log.Error().Err(err).
+				Str("channel_id", channelID).
+				Str("channel", channelName).
+				Msg("discord_bot.welcome.messages_fetching_failed")
+
+			return nil, fmt.Errorf("%w", err)
+		}
+
+		countScanned += len(messages)
+
+		for _, message := range messages {
+			if w.isMessageFromBot(message) && !treated.hasMessageID(message.ID) {
+				messagesFromBot = append(messagesFromBot, message)
+			}
+		}
+
+		if len(messages) < limit {
+			break
+		}
+
+		if !w.deleteUnknownMessages && w.hasFoundAllMessages(channelID, messagesFromBot, treated) {
+			break
+		}
+
+		if countScanned >= w.scanLimitMessages {
+			log.Info().
+				Str("channel_id", channelID).
+				Str("channel", channelName).
+				Int("scan_limit_messages", w.scanLimitMessages).
+				Msg("discord_bot.welcome.scan_limit_messages_reached")
+
+			break
+		}
+
+		beforeID = messages[len(messages)-1].ID
+	}
+
+	log.Info().
+		Str("channel_id", channelID).
+		Str("channel", channelName).
+		Msg("discord_bot.welcome.messages_fetched")
+
+	return messagesFromBot, nil
+}
+
+// hasFoundAllMessages returns true if each message of channel not treated has a message of the bot with same content or same key.
+func (w *Manager) hasFoundAllMessages(channelID string, messagesFromBot []*discordgo.Message, treated *messagesTreated) bool {
+	for idxMessage := range w.messages {
+		if w.messages[idxMessage].ChannelID != channelID || treated.hasMessage(idxMessage) {
+			continue
+		}
+
+		isFound := slices.ContainsFunc(messagesFromBot, func(message *discordgo.Message) bool {
+			return w.isSameMessageAgainstConfig(message, w.messages[idxMessage]) || w.isSameKeyAgainstConfig(message.Embeds[0], w.messages[idxMessage])
+		})
+		if !isFound {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (w *Manager) isMessageFromBot(message *discordgo.Message) bool {
+	return message.Author != nil && message.Author.ID == w.discordSession.State.User.ID && len(message.Embeds) > 0
+}
+
+func (w *Manager) isSameMessageAgainstConfig(messageFromDiscord *discordgo.Message, messageFromConfig Message) bool {
+	return isSameEmbed(messageFromDiscord.Embeds[0], buildEmbed(messageFromConfig)) &&
+		slices.Equal(componentsSignature(messageFromDiscord.Components), componentsSignature(w.buildComponents(messageFromConfig)))
+}
+
+// updateUserRoleBelongMessage applies roles to users who reacted to message.
+// Messages with components are skipped because there is no reaction to read from.
+// Users who reacted are kept in usersReacted to find members who have a role without reaction.
+func (w *Manager) updateUserRoleBelongMessage(message Message, choices exclusiveChoices, usersReacted usersByRole) error {
+	if message.usesComponents() {
+		return nil
+	}
+
+	for _, reaction := range message.Reactions {
+		err := w.updateUserRoleBelongReaction(message, reaction, choices, usersReacted)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 //nolint:funlen,cyclop
-func (w *Manager) updateUserRoleBelongMessage(message Message) error {
+func (w *Manager) updateUserRoleBelongReaction(message Message, reaction Reaction, choices exclusiveChoices, usersReacted usersByRole) error {
+	emoji := reaction.emojiAPIName()
+
 	log.Info().
 		Str("message_id", message.ID).
 		Str("message_title", message.Title).
-		Str("channel_id", w.channelID).
-		Str("channel", w.channelName).
-		Str("emoji", message.Emoji+":"+message.EmojiID).
+		Str("channel_id", message.ChannelID).
+		Str("channel", message.Channel).
+		Str("emoji", emoji).
 		Msg("discord_bot.welcome.fetching_reactions_message")
 
-	users, err := helpers.MessageReactionsAll(w.discordSession, w.channelID, message.ID, message.Emoji+":"+message.EmojiID)
+	users, err := helpers.MessageReactionsAll(w.discordSession, message.ChannelID, message.ID, emoji)
 	if err != nil {
 		log.Error().Err(err).
 			Str("message_id", message.ID).
-			Str("channel_id", w.channelID).
-			Str("channel", w.channelName).
-			Str("emoji", message.Emoji+":"+message.EmojiID).
+			Str("channel_id", message.ChannelID).
+			Str("channel", message.Channel).
+			Str("emoji", emoji).
 			Msg("discord_bot.welcome.reactions_message_fetching_failed")
 
 		return fmt.Errorf("%w", err)
@@ -164,30 +403,56 @@ func (w *Manager) updateUserRoleBelongMessage(message Message) error {
 			continue
 		}
 
-		skipUser := slices.Contains(member.Roles, message.RoleID)
+		// no direct message at startup, members could receive it again at each start
+		refusal := w.ageGateRefusal(message, reaction, user.ID, member)
+		if refusal != "" {
+			w.refuseReaction(message, reaction, user.ID, refusal, false)
+
+			continue
+		}
+
+		if !w.chooseReactionInExclusiveGroup(choices, message, reaction, user.ID) {
+			continue
+		}
+
+		usersReacted.add(reaction.RoleID, user.ID)
+
+		if message.CanPurgeReactions {
+			w.reactionsOfMembers.add(user.ID, message.ID, emoji)
+		}
+
+		skipUser := slices.Contains(member.Roles, reaction.RoleID)
 
 		if skipUser {
 			continue
 		}
 
 		log.Info().
-			Str("role_id", message.RoleID).
-			Str("role", message.Role).
+			Str("role_id", reaction.RoleID).
+			Str("role", reaction.Role).
 			Str("user_id", user.ID).
 			Str("username", user.Username).
 			Msg("discord_bot.welcome.adding_user_role_adding")
 
-		err = w.discordSession.GuildMemberRoleAdd(w.guildID, user.ID, message.RoleID)
+		if w.skipInDryRun(changeRoleAdd) {
+			continue
+		}
+
+		err = w.discordSession.GuildMemberRoleAdd(w.guildID, user.ID, reaction.RoleID)
 		if err != nil {
 			log.Error().Err(err).
-				Str("role_id", message.RoleID).
-				Str("role", message.Role).
+				Str("role_id", reaction.RoleID).
+				Str("role", reaction.Role).
 				Str("user_id", user.ID).
 				Str("username", user.Username).
 				Msg("discord_bot.welcome.user_role_adding_failed")
 
+			w.audit(auditRoleAddingFailed, user.ID, reaction.RoleID, err)
+
 			return fmt.Errorf("%w", err)
 		}
+
+		w.audit(auditRoleAdded, user.ID, reaction.RoleID, nil)
 	}
 
 	if len(membersNotInGuild) > 0 {
@@ -205,15 +470,19 @@ func (w *Manager) updateUserRoleBelongMessage(message Message) error {
 			for idx := range membersNotInGuild {
 				log.Info().
 					Str("message_id", message.ID).
-					Str("emoji", message.Emoji+":"+message.EmojiID).
+					Str("emoji", emoji).
 					Str("user_id", membersNotInGuild[idx]).
 					Msg("discord_bot.welcome.removing_reaction")
 
-				err = w.discordSession.MessageReactionRemove(w.channelID, message.ID, message.Emoji+":"+message.EmojiID, membersNotInGuild[idx])
+				if w.skipInDryRun(changeReactionRemove) {
+					continue
+				}
+
+				err = w.discordSession.MessageReactionRemove(message.ChannelID, message.ID, emoji, membersNotInGuild[idx])
 				if err != nil {
 					log.Error().Err(err).
 						Str("message_id", message.ID).
-						Str("emoji", message.Emoji+":"+message.EmojiID).
+						Str("emoji", emoji).
 						Str("user_id", membersNotInGuild[idx]).
 						Msg("discord_bot.welcome.reaction_removing_failed")
 				}
@@ -227,20 +496,34 @@ func (w *Manager) updateUserRoleBelongMessage(message Message) error {
 func (w *Manager) addMessage(message Message) (string, error) {
 	log.Info().
 		Str("message_title", message.Title).
-		Str("channel_id", w.channelID).
-		Str("channel", w.channelName).
+		Str("channel_id", message.ChannelID).
+		Str("channel", message.Channel).
 		Msg("discord_bot.welcome.adding_message")
 
-	messageSent, err := w.discordSession.ChannelMessageSendEmbed(w.channelID, &discordgo.MessageEmbed{
-		Title:       message.Title,
-		Description: message.Description,
-		Color:       message.Color,
-	})
+	if w.skipInDryRun(changeMessageAdd) {
+		return "", nil
+	}
+
+	embed := buildEmbed(message)
+
+	var (
+		messageSent *discordgo.Message
+		err         error
+	)
+
+	if message.usesComponents() {
+		messageSent, err = w.discordSession.ChannelMessageSendComplex(message.ChannelID, &discordgo.MessageSend{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: w.buildComponents(message),
+		})
+	} else {
+		messageSent, err = w.discordSession.ChannelMessageSendEmbed(message.ChannelID, embed)
+	}
 	if err != nil {
 		log.Error().Err(err).
 			Str("message_title", message.Title).
-			Str("channel_id", w.channelID).
-			Str("channel", w.channelName).
+			Str("channel_id", message.ChannelID).
+			Str("channel", message.Channel).
 			Msg("discord_bot.welcome.message_adding_failed")
 
 		return "", fmt.Errorf("%w", err)
@@ -248,25 +531,44 @@ func (w *Manager) addMessage(message Message) (string, error) {
 
 	log.Info().
 		Str("message_id", messageSent.ID).
-		Str("channel_id", w.channelID).
-		Str("channel", w.channelName).
+		Str("channel_id", message.ChannelID).
+		Str("channel", message.Channel).
 		Msg("discord_bot.welcome.message_added")
 
-	log.Info().
-		Str("message_id", messageSent.ID).
-		Str("message_title", message.Title).
-		Str("emoji", message.Emoji+":"+message.EmojiID).
-		Msg("discord_bot.welcome.adding_reaction")
+	if message.usesComponents() {
+		return messageSent.ID, nil
+	}
 
-	err = w.discordSession.MessageReactionAdd(w.channelID, messageSent.ID, message.Emoji+":"+message.EmojiID)
+	err = w.addReactions(message.ChannelID, messageSent.ID, message.Title, message.Reactions)
 	if err != nil {
-		log.Error().Err(err).
-			Str("message_id", messageSent.ID).
-			Str("emoji", message.Emoji+":"+message.EmojiID).
-			Msg("discord_bot.welcome.reaction_adding_failed")
-
-		return "", fmt.Errorf("%w", err)
+		return "", err
 	}
 
 	return messageSent.ID, nil
 }
+
+func (w *Manager) addReactions(channelID string, messageID string, messageTitle string, reactions []Reaction) error {
+	for _, reaction := range reactions {
+		log.Info().
+			Str("message_id", messageID).
+			Str("message_title", messageTitle).
+			Str("emoji", reaction.emojiAPIName()).
+			Msg("discord_bot.welcome.adding_reaction")
+
+		if w.skipInDryRun(changeReactionAdd) {
+			continue
+		}
+
+		err := w.discordSession.MessageReactionAdd(channelID, messageID, reaction.emojiAPIName())
+		if err != nil {
+			log.Error().Err(err).
+				Str("message_id", messageID).
+				Str("emoji", reaction.emojiAPIName()).
+				Msg("discord_bot.welcome.reaction_adding_failed")
+
+			return fmt.Errorf("%w", err)
+		}
+	}
+
+	return nil
+}
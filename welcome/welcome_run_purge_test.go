@@ -31,10 +31,10 @@ func TestRunPurge(t *testing.T) {
 		Name:     guildName,
 		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
 		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
-		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
 		Members: []*discordgo.Member{
 			{User: &discordgo.User{ID: "user-id-456"}},
-			{User: &discordgo.User{ID: "bot-123"}},
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}},
 			{User: &discordgo.User{ID: "user-id-789"}, Roles: []string{"role-123"}},
 		},
 	})
@@ -224,14 +224,15 @@ func TestRunPurge(t *testing.T) {
 		parts := strings.Split(bufferLogs.String(), "\n")
 		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.add_handler_on_message_reaction_add"}`, parts[0])
 		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.add_handler_on_message_reaction_remove"}`, parts[1])
-		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.adding_messages"}`, parts[2])
-		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.fetching_messages"}`, parts[3])
-		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.messages_fetched"}`, parts[4])
-		require.JSONEq(t, `{"level":"info","message_id":"104","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[5])
-		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-456","username":"user lambda 456","message":"discord_bot.welcome.adding_user_role_adding"}`, parts[6])
-		require.JSONEq(t, `{"level":"info","count_members_reacted":4,"count_members_not_found":1,"message":"discord_bot.welcome.members_not_in_guild"}`, parts[7])
-		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[8])
-		require.Empty(t, parts[9])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.add_handler_on_guild_member_remove"}`, parts[2])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.adding_messages"}`, parts[3])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.fetching_messages"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.messages_fetched"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message_id":"104","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-456","username":"user lambda 456","message":"discord_bot.welcome.adding_user_role_adding"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","count_members_reacted":4,"count_members_not_found":1,"message":"discord_bot.welcome.members_not_in_guild"}`, parts[8])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[9])
+		require.Empty(t, parts[10])
 	})
 
 	t.Run("should not purge because count members not in discord is equal or greater than PurgeBelowCountMembersNotInGuild", func(t *testing.T) {
@@ -319,14 +320,15 @@ func TestRunPurge(t *testing.T) {
 		parts := strings.Split(bufferLogs.String(), "\n")
 		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.add_handler_on_message_reaction_add"}`, parts[0])
 		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.add_handler_on_message_reaction_remove"}`, parts[1])
-		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.adding_messages"}`, parts[2])
-		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.fetching_messages"}`, parts[3])
-		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.messages_fetched"}`, parts[4])
-		require.JSONEq(t, `{"level":"info","message_id":"104","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[5])
-		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-456","username":"user lambda 456","message":"discord_bot.welcome.adding_user_role_adding"}`, parts[6])
-		require.JSONEq(t, `{"level":"info","count_members_reacted":4,"count_members_not_found":1,"message":"discord_bot.welcome.members_not_in_guild"}`, parts[7])
-		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[8])
-		require.Empty(t, parts[9])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.add_handler_on_guild_member_remove"}`, parts[2])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.adding_messages"}`, parts[3])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.fetching_messages"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.messages_fetched"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message_id":"104","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-456","username":"user lambda 456","message":"discord_bot.welcome.adding_user_role_adding"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","count_members_reacted":4,"count_members_not_found":1,"message":"discord_bot.welcome.members_not_in_guild"}`, parts[8])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[9])
+		require.Empty(t, parts[10])
 	})
 
 	t.Run("should do purge", func(t *testing.T) {
@@ -431,17 +433,18 @@ func TestRunPurge(t *testing.T) {
 		parts := strings.Split(bufferLogs.String(), "\n")
 		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.add_handler_on_message_reaction_add"}`, parts[0])
 		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.add_handler_on_message_reaction_remove"}`, parts[1])
-		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.adding_messages"}`, parts[2])
-		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.fetching_messages"}`, parts[3])
-		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.messages_fetched"}`, parts[4])
-		require.JSONEq(t, `{"level":"info","message_id":"104","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[5])
-		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-456","username":"user lambda 456","message":"discord_bot.welcome.adding_user_role_adding"}`, parts[6])
-		require.JSONEq(t, `{"level":"info","count_members_reacted":5,"count_members_not_found":2,"message":"discord_bot.welcome.members_not_in_guild"}`, parts[7])
-		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.purge_reactions"}`, parts[8])
-		require.JSONEq(t, `{"level":"info","message_id":"104","emoji":"my-emoji-1:emoji-123","user_id":"456","message":"discord_bot.welcome.removing_reaction"}`, parts[9])
-		require.JSONEq(t, `{"level":"info","message_id":"104","emoji":"my-emoji-1:emoji-123","user_id":"678","message":"discord_bot.welcome.removing_reaction"}`, parts[10])
-		require.JSONEq(t, `{"level":"error","error":"HTTP 500 Internal Server Error, ","message_id":"104","emoji":"my-emoji-1:emoji-123","user_id":"678","message":"discord_bot.welcome.reaction_removing_failed"}`, parts[11])
-		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[12])
-		require.Empty(t, parts[13])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.add_handler_on_guild_member_remove"}`, parts[2])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.adding_messages"}`, parts[3])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.fetching_messages"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.messages_fetched"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message_id":"104","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","role_id":"role-123","role":"my role 1","user_id":"user-id-456","username":"user lambda 456","message":"discord_bot.welcome.adding_user_role_adding"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","count_members_reacted":5,"count_members_not_found":2,"message":"discord_bot.welcome.members_not_in_guild"}`, parts[8])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.purge_reactions"}`, parts[9])
+		require.JSONEq(t, `{"level":"info","message_id":"104","emoji":"my-emoji-1:emoji-123","user_id":"456","message":"discord_bot.welcome.removing_reaction"}`, parts[10])
+		require.JSONEq(t, `{"level":"info","message_id":"104","emoji":"my-emoji-1:emoji-123","user_id":"678","message":"discord_bot.welcome.removing_reaction"}`, parts[11])
+		require.JSONEq(t, `{"level":"error","error":"HTTP 500 Internal Server Error, ","message_id":"104","emoji":"my-emoji-1:emoji-123","user_id":"678","message":"discord_bot.welcome.reaction_removing_failed"}`, parts[12])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[13])
+		require.Empty(t, parts[14])
 	})
 }
@@ -32,10 +32,10 @@ func TestRun(t *testing.T) {
 		Name:     guildName,
 		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
 		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
-		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
 		Members: []*discordgo.Member{
 			{User: &discordgo.User{ID: "user-id-456"}},
-			{User: &discordgo.User{ID: "bot-123"}},
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}},
 			{User: &discordgo.User{ID: "user-id-789"}, Roles: []string{"role-123"}},
 		},
 	})
@@ -237,10 +237,10 @@ func TestRun_Errors(t *testing.T) {
 		Name:     guildName,
 		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
 		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
-		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-bot", Name: "bot", Position: 1, Permissions: discordgo.PermissionAdministrator}},
 		Members: []*discordgo.Member{
 			{User: &discordgo.User{ID: "user-id-456"}},
-			{User: &discordgo.User{ID: "bot-123"}},
+			{User: &discordgo.User{ID: "bot-123"}, Roles: []string{"role-bot"}},
 			{User: &discordgo.User{ID: "user-id-789"}, Roles: []string{"role-123"}},
 		},
 	})
@@ -556,6 +556,185 @@ func TestRun_Errors(t *testing.T) {
 	})
 }
 
+func TestRun_UnicodeEmoji(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{Title: "my title 1", Emoji: "✅", Role: "my role 1"},
+		},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	bufferLogs.Reset()
+
+	data1, err := json.Marshal([]*discordgo.Message{})
+	require.NoError(t, err)
+
+	recorder1 := httptest.NewRecorder()
+	recorder1.Header().Add("Content-Type", "application/json")
+	_, err = recorder1.Write(data1)
+	require.NoError(t, err)
+
+	expectedResponse1 := recorder1.Result()
+	defer expectedResponse1.Body.Close()
+
+	data2, err := json.Marshal(discordgo.Message{ID: "123"})
+	require.NoError(t, err)
+
+	recorder2 := httptest.NewRecorder()
+	recorder2.Header().Add("Content-Type", "application/json")
+	_, err = recorder2.Write(data2)
+	require.NoError(t, err)
+
+	expectedResponse2 := recorder2.Result()
+	defer expectedResponse2.Body.Close()
+
+	recorder3 := httptest.NewRecorder()
+
+	expectedResponse3 := recorder3.Result()
+	defer expectedResponse3.Body.Close()
+
+	session.Client = createClient(t,
+		[]*http.Response{expectedResponse1, expectedResponse2, expectedResponse3},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			{
+				method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-123/messages",
+				body: `{"embeds":[{"type":"rich","title":"my title 1"}],"tts":false,"components":null,"sticker_ids":null}`,
+			},
+			{method: "PUT", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/%E2%9C%85/@me"},
+		},
+	)
+
+	err = welcomeManager.Run()
+	require.NoError(t, err)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"info","message_id":"123","message_title":"my title 1","emoji":"✅","message":"discord_bot.welcome.adding_reaction"}`, parts[8])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[10])
+	require.Empty(t, parts[11])
+}
+
+//nolint:funlen
+func TestRun_MultipleReactions(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-456", Name: "my role 2"}},
+		Members: []*discordgo.Member{
+			{User: &discordgo.User{ID: "user-id-456"}, Roles: []string{"role-123"}},
+		},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{Title: "my title 1", Reactions: []welcome.Reaction{{Emoji: "my-emoji-1", Role: "my role 1"}, {Emoji: "✅", Role: "my role 2"}}},
+		},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	t.Run("should add message and add all reactions because message is not found", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{}),
+				createJSONResponse(t, discordgo.Message{ID: "123"}),
+				createEmptyResponse(t),
+				createEmptyResponse(t),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{
+					method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-123/messages",
+					body: `{"embeds":[{"type":"rich","title":"my title 1"}],"tts":false,"components":null,"sticker_ids":null}`,
+				},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/my-emoji-1:emoji-123/@me"},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/123/reactions/%E2%9C%85/@me"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message_id":"123","message_title":"my title 1","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.adding_reaction"}`, parts[8])
+		require.JSONEq(t, `{"level":"info","message_id":"123","message_title":"my title 1","emoji":"✅","message":"discord_bot.welcome.adding_reaction"}`, parts[9])
+		require.JSONEq(t, `{"level":"info","message_title":"my title 1","message":"discord_bot.welcome.missed_messages_added"}`, parts[10])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[11])
+		require.Empty(t, parts[12])
+	})
+
+	t.Run("should find message and update roles for each reaction", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{
+					{ID: "104", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}},
+				}),
+				createJSONResponse(t, []discordgo.User{{ID: "user-id-456", Username: "user lambda 456"}}),
+				createJSONResponse(t, []discordgo.User{}),
+				createJSONResponse(t, []discordgo.User{{ID: "user-id-456", Username: "user lambda 456"}}),
+				createJSONResponse(t, []discordgo.User{}),
+				createEmptyResponse(t),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/104/reactions/my-emoji-1:emoji-123?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/104/reactions/my-emoji-1:emoji-123?after=user-id-456&limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/104/reactions/%E2%9C%85?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/104/reactions/%E2%9C%85?after=user-id-456&limit=100"},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/guilds/guild-123/members/user-id-456/roles/role-456"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message_id":"104","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message_id":"104","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"✅","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","role_id":"role-456","role":"my role 2","user_id":"user-id-456","username":"user lambda 456","message":"discord_bot.welcome.adding_user_role_adding"}`, parts[7])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[8])
+		require.Empty(t, parts[9])
+	})
+}
+
 type mockRoundTripper struct {
 	idxResponse     int
 	test            *testing.T
@@ -577,7 +756,7 @@ func (r requestTest) assert(t *testing.T, req *http.Request) {
 	require.Equal(t, r.host, req.Host)
 	require.Equal(t, r.uri, req.URL.RequestURI())
 
-	if req.Method == http.MethodPost {
+	if req.Method == http.MethodPost || req.Method == http.MethodPatch {
 		bodyData, err := io.ReadAll(req.Body)
 		require.NoError(t, err)
 		require.Equal(t, r.body, string(bodyData))
@@ -606,3 +785,40 @@ func createClient(t *testing.T, responses []*http.Response, requests []requestTe
 		},
 	}
 }
+
+func createJSONResponse(t *testing.T, value any) *http.Response {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	recorder.Header().Add("Content-Type", "application/json")
+	_, err = recorder.Write(data)
+	require.NoError(t, err)
+
+	response := recorder.Result()
+	t.Cleanup(func() { response.Body.Close() })
+
+	return response
+}
+
+func createEmptyResponse(t *testing.T) *http.Response {
+	t.Helper()
+
+	response := httptest.NewRecorder().Result()
+	t.Cleanup(func() { response.Body.Close() })
+
+	return response
+}
+
+func createErrorResponse(t *testing.T) *http.Response {
+	t.Helper()
+
+	response := httptest.NewRecorder().Result()
+	response.Status = internalServerError
+	response.StatusCode = http.StatusInternalServerError
+	t.Cleanup(func() { response.Body.Close() })
+
+	return response
+}
@@ -0,0 +1,143 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:funlen
+func TestRun_ScanChannel(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	t.Run("should page backwards until message is found", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, createUserMessages(1000, 100)),
+				createJSONResponse(t, slices.Concat(
+					createUserMessages(900, 50),
+					[]*discordgo.Message{{ID: "200", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1"}}}},
+					createUserMessages(800, 49),
+				)),
+				createJSONResponse(t, []discordgo.User{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?before=901&limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.messages_fetched"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[6])
+		require.Empty(t, parts[7])
+	})
+
+	t.Run("should stop scanning when limit is reached", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:           "my-channel",
+			Messages:          []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			ScanLimitMessages: 150,
+		}, guildName, session)
+		require.NotNil(t, welcomeManager)
+
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, createUserMessages(1000, 100)),
+				createJSONResponse(t, createUserMessages(900, 50)),
+				createJSONResponse(t, discordgo.Message{ID: "300"}),
+				createEmptyResponse(t),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?before=901&limit=50"},
+				{
+					method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-123/messages",
+					body: `{"embeds":[{"type":"rich","title":"my title 1"}],"tts":false,"components":null,"sticker_ids":null}`,
+				},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/300/reactions/my-emoji-1:emoji-123/@me"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","scan_limit_messages":150,"message":"discord_bot.welcome.scan_limit_messages_reached"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.messages_fetched"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message_title":"my title 1","message":"discord_bot.welcome.adding_missed_messages"}`, parts[6])
+	})
+
+	t.Run("should return nil because scan limit is negative", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:           "my-channel",
+			Messages:          []welcome.Message{{Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+			ScanLimitMessages: -1,
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","scan_limit_messages":-1,"message":"discord_bot.welcome.configuration_invalid_scan_limit_messages"}`, parts[1])
+	})
+}
+
+// createUserMessages returns count messages from a user, IDs are decreasing from firstID like in Discord history.
+func createUserMessages(firstID int, count int) []*discordgo.Message {
+	messages := make([]*discordgo.Message, 0, count)
+
+	for idx := range count {
+		messages = append(messages, &discordgo.Message{ID: strconv.Itoa(firstID - idx), Author: &discordgo.User{ID: "user-123"}})
+	}
+
+	return messages
+}
@@ -0,0 +1,196 @@
+package welcome
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	permissionStateDirectory = 0o750
+	permissionStateFile      = 0o600
+)
+
+// state is saved in stateFilename after messages are added to channel.
+type state struct {
+	Messages []stateMessage `json:"messages"`
+}
+
+// stateMessage links a configured message, by its key, to the message published in Discord.
+type stateMessage struct {
+	Key       string   `json:"key"`
+	MessageID string   `json:"message_id"`
+	ChannelID string   `json:"channel_id"`
+	Emojis    []string `json:"emojis"`
+}
+
+func (s state) find(key string) (stateMessage, bool) {
+	for _, message := range s.Messages {
+		if message.Key == key {
+			return message, true
+		}
+	}
+
+	return stateMessage{}, false
+}
+
+// findMessagesFromState fetches messages saved in state, messages not found are left to the channel scan.
+func (w *Manager) findMessagesFromState(treated *messagesTreated) error {
+	stateSaved := w.readState()
+
+	for idxMessage := range w.messages {
+		messageSaved, found := stateSaved.find(w.messages[idxMessage].key())
+		if !found || messageSaved.ChannelID != w.messages[idxMessage].ChannelID || treated.hasMessageID(messageSaved.MessageID) {
+			continue
+		}
+
+		log.Info().
+			Str("message_id", messageSaved.MessageID).
+			Str("message_title", w.messages[idxMessage].Title).
+			Str("channel_id", w.messages[idxMessage].ChannelID).
+			Str("channel", w.messages[idxMessage].Channel).
+			Msg("discord_bot.welcome.fetching_message_from_state")
+
+		message, err := w.discordSession.ChannelMessage(messageSaved.ChannelID, messageSaved.MessageID)
+		if err != nil {
+			log.Error().Err(err).
+				Str("message_id", messageSaved.MessageID).
+				Str("channel_id", w.messages[idxMessage].ChannelID).
+				Str("channel", w.messages[idxMessage].Channel).
+				Msg("discord_bot.welcome.message_from_state_fetching_failed")
+
+			continue
+		}
+
+		if !w.isMessageFromBot(message) {
+			continue
+		}
+
+		if !w.isSameMessageAgainstConfig(message, w.messages[idxMessage]) {
+			err = w.editMessage(message, w.messages[idxMessage])
+			if err != nil {
+				return err
+			}
+		}
+
+		w.messages[idxMessage].ID = message.ID
+
+		treated.add(idxMessage, message.ID)
+	}
+
+	return nil
+}
+
+// readState returns an empty state when file is missing or invalid, channel is scanned in that case.
+func (w *Manager) readState() state {
+	stateSaved := state{}
+
+	log.Info().
+		Str("state_filename", w.stateFilename).
+		Msg("discord_bot.welcome.reading_state")
+
+	data, err := os.ReadFile(w.stateFilename)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Info().
+			Str("state_filename", w.stateFilename).
+			Msg("discord_bot.welcome.state_not_found")
+
+		return stateSaved
+	}
+
+	if err != nil {
+		log.Error().Err(err).
+			Str("state_filename", w.stateFilename).
+			Msg("discord_bot.welcome.state_reading_failed")
+
+		return stateSaved
+	}
+
+	err = json.Unmarshal(data, &stateSaved)
+	if err != nil {
+		log.Error().Err(err).
+			Str("state_filename", w.stateFilename).
+			Msg("discord_bot.welcome.state_reading_failed")
+
+		return state{}
+	}
+
+	log.Info().
+		Str("state_filename", w.stateFilename).
+		Int("count_messages", len(stateSaved.Messages)).
+		Msg("discord_bot.welcome.state_read")
+
+	return stateSaved
+}
+
+func (w *Manager) saveState() {
+	stateToSave := state{Messages: make([]stateMessage, 0, len(w.messages))}
+
+	for _, message := range w.messages {
+		if message.ID == "" {
+			continue
+		}
+
+		emojis := make([]string, 0, len(message.Reactions))
+		for _, reaction := range message.Reactions {
+			emojis = append(emojis, reaction.emojiAPIName())
+		}
+
+		stateToSave.Messages = append(stateToSave.Messages, stateMessage{
+			Key:       message.key(),
+			MessageID: message.ID,
+			ChannelID: message.ChannelID,
+			Emojis:    emojis,
+		})
+	}
+
+	log.Info().
+		Str("state_filename", w.stateFilename).
+		Int("count_messages", len(stateToSave.Messages)).
+		Msg("discord_bot.welcome.saving_state")
+
+	err := writeState(w.stateFilename, stateToSave)
+	if err != nil {
+		log.Error().Err(err).
+			Str("state_filename", w.stateFilename).
+			Msg("discord_bot.welcome.state_saving_failed")
+
+		return
+	}
+
+	log.Info().
+		Str("state_filename", w.stateFilename).
+		Msg("discord_bot.welcome.state_saved")
+}
+
+// writeState writes in a temporary file first to never leave a truncated state.
+func writeState(filename string, stateToSave state) error {
+	data, err := json.MarshalIndent(stateToSave, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(filename), permissionStateDirectory)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	filenameTemporary := filename + ".tmp"
+
+	err = os.WriteFile(filenameTemporary, data, permissionStateFile)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	err = os.Rename(filenameTemporary, filename)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:funlen
+func TestRun_State(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	stateFilename := filepath.Join(t.TempDir(), "state", "welcome.json")
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:       "my-channel",
+		Messages:      []welcome.Message{{Key: "rules", Title: "my title 1", Emoji: "my-emoji-1", Role: "my role 1"}},
+		StateFilename: stateFilename,
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	t.Run("should scan channel and save state because state file is missing", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, []*discordgo.Message{}),
+				createJSONResponse(t, discordgo.Message{ID: "200"}),
+				createEmptyResponse(t),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{
+					method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-123/messages",
+					body: `{"embeds":[{"type":"rich","title":"my title 1","footer":{"text":"rules"}}],"tts":false,"components":null,"sticker_ids":null}`,
+				},
+				{method: "PUT", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123/@me"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","state_filename":"`+stateFilename+`","message":"discord_bot.welcome.reading_state"}`, parts[3])
+		require.JSONEq(t, `{"level":"info","state_filename":"`+stateFilename+`","message":"discord_bot.welcome.state_not_found"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.fetching_messages"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","state_filename":"`+stateFilename+`","count_messages":1,"message":"discord_bot.welcome.saving_state"}`, parts[12])
+		require.JSONEq(t, `{"level":"info","state_filename":"`+stateFilename+`","message":"discord_bot.welcome.state_saved"}`, parts[13])
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.messages_added"}`, parts[14])
+		require.Empty(t, parts[15])
+
+		data, err := os.ReadFile(stateFilename)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"messages":[{"key":"rules","message_id":"200","channel_id":"channel-123","emojis":["my-emoji-1:emoji-123"]}]}`, string(data))
+	})
+
+	t.Run("should fetch message from state without scanning channel", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createJSONResponse(t, discordgo.Message{
+					ID: "200", Author: &discordgo.User{ID: "bot-123"},
+					Embeds: []*discordgo.MessageEmbed{{Title: "my title 1", Footer: &discordgo.MessageEmbedFooter{Text: "rules"}}},
+				}),
+				createJSONResponse(t, []discordgo.User{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","state_filename":"`+stateFilename+`","message":"discord_bot.welcome.reading_state"}`, parts[3])
+		require.JSONEq(t, `{"level":"info","state_filename":"`+stateFilename+`","count_messages":1,"message":"discord_bot.welcome.state_read"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.fetching_message_from_state"}`, parts[5])
+		require.JSONEq(t, `{"level":"info","message_id":"200","message_title":"my title 1","channel_id":"channel-123","channel":"my-channel","emoji":"my-emoji-1:emoji-123","message":"discord_bot.welcome.fetching_reactions_message"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","state_filename":"`+stateFilename+`","count_messages":1,"message":"discord_bot.welcome.saving_state"}`, parts[7])
+	})
+
+	t.Run("should scan channel because message from state is not found", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		session.Client = createClient(t,
+			[]*http.Response{
+				createErrorResponse(t),
+				createJSONResponse(t, []*discordgo.Message{
+					{ID: "201", Author: &discordgo.User{ID: "bot-123"}, Embeds: []*discordgo.MessageEmbed{{Title: "my title 1", Footer: &discordgo.MessageEmbedFooter{Text: "rules"}}}},
+				}),
+				createJSONResponse(t, []discordgo.User{}),
+			},
+			[]requestTest{
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+				{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/201/reactions/my-emoji-1:emoji-123?limit=100"},
+			},
+		)
+
+		err = welcomeManager.Run()
+		require.NoError(t, err)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","error":"HTTP 500 Internal Server Error, ","message_id":"200","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.message_from_state_fetching_failed"}`, parts[6])
+		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.fetching_messages"}`, parts[7])
+
+		data, err := os.ReadFile(stateFilename)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"messages":[{"key":"rules","message_id":"201","channel_id":"channel-123","emojis":["my-emoji-1:emoji-123"]}]}`, string(data))
+	})
+}
+
+func TestRun_StateKeyOfConfiguredTitle(t *testing.T) {
+	log.Logger = zerolog.Nop()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	stateFilename := filepath.Join(t.TempDir(), "welcome.json")
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:       "my-channel",
+		Messages:      []welcome.Message{{Title: "my title :my-emoji-1:", Emoji: "my-emoji-1", Role: "my role 1"}},
+		StateFilename: stateFilename,
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	session.Client = createClient(t,
+		[]*http.Response{
+			createJSONResponse(t, []*discordgo.Message{}),
+			createJSONResponse(t, discordgo.Message{ID: "200"}),
+			createEmptyResponse(t),
+		},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			{
+				method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-123/messages",
+				body: `{"embeds":[{"type":"rich","title":"my title \u003c:my-emoji-1:emoji-123\u003e"}],"tts":false,"components":null,"sticker_ids":null}`,
+			},
+			{method: "PUT", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123/@me"},
+		},
+	)
+
+	err = welcomeManager.Run()
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(stateFilename)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"messages":[{"key":"my title :my-emoji-1:","message_id":"200","channel_id":"channel-123","emojis":["my-emoji-1:emoji-123"]}]}`, string(data))
+}
@@ -0,0 +1,84 @@
+package welcome
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	placeholderGuildName   string = "{guild.name}"
+	placeholderMemberCount string = "{member_count}"
+
+	referenceChannel string = "channel"
+	referenceRole    string = "role"
+)
+
+// templateReference matches {channel:name} and {role:name}, name can also be an ID.
+var templateReference = regexp.MustCompile(`\{(channel|role):([^{}]+)\}`)
+
+// renderTemplate replaces references and variables of text with values of guild.
+// Unresolved references are kept in text, they are reported by hasValidTemplatesAgainstDiscordServer.
+func renderTemplate(guild *discordgo.Guild, text string) string {
+	text = templateReference.ReplaceAllStringFunc(text, func(reference string) string {
+		match := templateReference.FindStringSubmatch(reference)
+
+		mentions := resolveReference(guild, match[1], match[2])
+		if len(mentions) != 1 {
+			return reference
+		}
+
+		return mentions[0]
+	})
+
+	return strings.NewReplacer(
+		placeholderGuildName, guild.Name,
+		placeholderMemberCount, strconv.Itoa(guild.MemberCount),
+	).Replace(text)
+}
+
+// resolveReference returns mentions of channels or roles matching value, several mentions when value is ambiguous.
+func resolveReference(guild *discordgo.Guild, kind string, value string) []string {
+	mentions := []string{}
+
+	switch kind {
+	case referenceChannel:
+		for _, channel := range findChannels(guild, "", value) {
+			mentions = append(mentions, channel.Mention())
+		}
+	case referenceRole:
+		for _, role := range findRoles(guild, "", value) {
+			mentions = append(mentions, role.Mention())
+		}
+	}
+
+	return mentions
+}
+
+func hasValidTemplatesAgainstDiscordServer(guild *discordgo.Guild, idxMessage int, message Message) bool {
+	for _, text := range message.texts() {
+		for _, match := range templateReference.FindAllStringSubmatch(*text, -1) {
+			if len(resolveReference(guild, match[1], match[2])) > 1 {
+				log.Error().
+					Int("message index", idxMessage).
+					Str("reference", match[0]).
+					Str("help", "Several "+match[1]+"s have this name, use its ID instead").
+					Msg("discord_bot.welcome.configuration_template_reference_ambiguous")
+
+				return false
+			}
+
+			log.Error().
+				Int("message index", idxMessage).
+				Str("reference", match[0]).
+				Msg("discord_bot.welcome.configuration_template_reference_missed")
+
+			return false
+		}
+	}
+
+	return true
+}
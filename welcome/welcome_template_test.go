@@ -0,0 +1,186 @@
+//nolint:paralleltest
+package welcome_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blueprintue/discord-bot/welcome"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWelcomeManager_ErrorTemplateReference(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}, {ID: "channel-456", Name: "rules"}, {ID: "channel-789", Name: "rules"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+	})
+	require.NoError(t, err)
+
+	t.Run("should return nil because channel reference is missed", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Description: "read {channel:faq}", Emoji: "my-emoji-1", Role: "my role 1"}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","message index":0,"reference":"{channel:faq}","message":"discord_bot.welcome.configuration_template_reference_missed"}`, parts[len(parts)-3])
+		require.JSONEq(t, `{"level":"error","step":2,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[len(parts)-2])
+		require.Empty(t, parts[len(parts)-1])
+	})
+
+	t.Run("should return nil because channel reference is ambiguous", func(t *testing.T) {
+		bufferLogs.Reset()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel:  "my-channel",
+			Messages: []welcome.Message{{Title: "my title 1", Description: "read {channel:rules}", Emoji: "my-emoji-1", Role: "my role 1"}},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"error","message index":0,"reference":"{channel:rules}","help":"Several channels have this name, use its ID instead","message":"discord_bot.welcome.configuration_template_reference_ambiguous"}`, parts[len(parts)-3])
+		require.JSONEq(t, `{"level":"error","step":2,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[len(parts)-2])
+		require.Empty(t, parts[len(parts)-1])
+	})
+}
+
+func TestRun_Template(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:          "guild-123",
+		Name:        guildName,
+		MemberCount: 42,
+		Channels:    []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}, {ID: "channel-456", Name: "rules"}},
+		Emojis:      []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:       []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-456", Name: "Moderator"}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{{
+			Title:       "Welcome to {guild.name}",
+			Description: "{member_count} members, read {channel:rules} or ask a {role:Moderator}",
+			Key:         "welcome",
+			Emoji:       "my-emoji-1",
+			Role:        "my role 1",
+		}},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	session.Client = createClient(t,
+		[]*http.Response{
+			createJSONResponse(t, []*discordgo.Message{}),
+			createJSONResponse(t, discordgo.Message{ID: "200"}),
+			createEmptyResponse(t),
+		},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			{method: "POST", host: "discord.com", uri: "/api/v9/channels/channel-123/messages", body: `{"embeds":[{"type":"rich","title":"Welcome to ` + guildName + `","description":"42 members, read \u003c#channel-456\u003e or ask a \u003c@\u0026role-456\u003e","footer":{"text":"welcome"}}],"tts":false,"components":null,"sticker_ids":null}`},
+			{method: "PUT", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123/@me"},
+		},
+	)
+
+	err = welcomeManager.Run()
+	require.NoError(t, err)
+}
+
+func TestNewWelcomeManager_ErrorVariableInTitleWithoutKey(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:  "my-channel",
+		Messages: []welcome.Message{{Title: "Welcome to {guild.name}", Emoji: "my-emoji-1", Role: "my role 1"}},
+	}, guildName, session)
+	require.Nil(t, welcomeManager)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"error","message index":0,"title":"Welcome to {guild.name}","help":"Set key, the message is found by its title which changes with {guild.name} and {member_count}","message":"discord_bot.welcome.configuration_variable_in_title_without_key_message"}`, parts[len(parts)-3])
+	require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[len(parts)-2])
+	require.Empty(t, parts[len(parts)-1])
+}
+
+func TestRun_TemplateMemberCountChanged(t *testing.T) {
+	log.Logger = zerolog.Nop()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	err = session.State.GuildAdd(&discordgo.Guild{
+		ID:          "guild-123",
+		Name:        guildName,
+		MemberCount: 43,
+		Channels:    []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:      []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:       []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+	})
+	require.NoError(t, err)
+
+	session.State.User = &discordgo.User{
+		ID: "bot-123",
+	}
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel:  "my-channel",
+		Messages: []welcome.Message{{Key: "welcome", Title: "{member_count} members", Emoji: "my-emoji-1", Role: "my role 1"}},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	// message published when the Discord server had 42 members, it is edited instead of published again
+	session.Client = createClient(t,
+		[]*http.Response{
+			createJSONResponse(t, []*discordgo.Message{{
+				ID: "200", Author: &discordgo.User{ID: "bot-123"},
+				Embeds:    []*discordgo.MessageEmbed{{Title: "42 members", Footer: &discordgo.MessageEmbedFooter{Text: "welcome"}}},
+				Reactions: []*discordgo.MessageReactions{{Me: true, Emoji: &discordgo.Emoji{ID: "emoji-123", Name: "my-emoji-1"}}},
+			}}),
+			createJSONResponse(t, discordgo.Message{ID: "200"}),
+			createJSONResponse(t, []discordgo.User{}),
+		},
+		[]requestTest{
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages?limit=100"},
+			{
+				method: "PATCH", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200",
+				body: `{"embeds":[{"type":"rich","title":"43 members","footer":{"text":"welcome"}}],"ID":"200","Channel":"channel-123"}`,
+			},
+			{method: "GET", host: "discord.com", uri: "/api/v9/channels/channel-123/messages/200/reactions/my-emoji-1:emoji-123?limit=100"},
+		},
+	)
+
+	err = welcomeManager.Run()
+	require.NoError(t, err)
+}
@@ -47,10 +47,11 @@ func TestNewWelcomeManager(t *testing.T) {
 	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
 	require.JSONEq(t, `{"level":"info","guild_id":"guild-123","guild":"guild-name","message":"discord_bot.welcome.set_guild_id"}`, parts[1])
 	require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.set_channel_id"}`, parts[2])
-	require.JSONEq(t, `{"level":"info","message index":0,"role_id":"role-123","role":"my role 1","message":"discord_bot.welcome.set_role_id"}`, parts[3])
-	require.JSONEq(t, `{"level":"info","message index":0,"emoji_id":"emoji-123","emoji":"my-emoji-1","message":"discord_bot.welcome.set_emoji_id"}`, parts[4])
-	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.configuration_validated"}`, parts[5])
-	require.Empty(t, parts[6])
+	require.JSONEq(t, `{"level":"info","message index":0,"reaction index":0,"role_id":"role-123","role":"my role 1","message":"discord_bot.welcome.set_role_id"}`, parts[3])
+	require.JSONEq(t, `{"level":"info","message index":0,"reaction index":0,"emoji_id":"emoji-123","emoji":"my-emoji-1","message":"discord_bot.welcome.set_emoji_id"}`, parts[4])
+	require.JSONEq(t, `{"level":"warn","guild":"guild-name","message":"discord_bot.welcome.permissions_check_skipped"}`, parts[5])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.configuration_validated"}`, parts[6])
+	require.Empty(t, parts[7])
 }
 
 //nolint:funlen
@@ -139,7 +140,47 @@ func TestNewWelcomeManager_ErrorHasValidConfigurationInFile(t *testing.T) {
 
 		parts := strings.Split(bufferLogs.String(), "\n")
 		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
-		require.JSONEq(t, `{"level":"error","message index":0,"message":"discord_bot.welcome.configuration_empty_role_message"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","message index":0,"reaction index":0,"message":"discord_bot.welcome.configuration_empty_role_message"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should return nil because reactions[1].role is empty", func(t *testing.T) {
+		var bufferLogs bytes.Buffer
+
+		log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel: "my-channel",
+			Messages: []welcome.Message{
+				{Title: "my title", Reactions: []welcome.Reaction{{Emoji: "my-emoji-1", Role: "my role 1"}, {Emoji: "my-emoji-2"}}},
+			},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+		require.JSONEq(t, `{"level":"error","message index":0,"reaction index":1,"message":"discord_bot.welcome.configuration_empty_role_message"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
+		require.Empty(t, parts[3])
+	})
+
+	t.Run("should return nil because emoji is used twice in the same message", func(t *testing.T) {
+		var bufferLogs bytes.Buffer
+
+		log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+		welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+			Channel: "my-channel",
+			Messages: []welcome.Message{
+				{Title: "my title", Emoji: "my-emoji-1", Role: "my role 1", Reactions: []welcome.Reaction{{Emoji: "my-emoji-1", Role: "my role 2"}}},
+			},
+		}, guildName, session)
+		require.Nil(t, welcomeManager)
+
+		parts := strings.Split(bufferLogs.String(), "\n")
+		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+		require.JSONEq(t, `{"level":"error","message index":0,"reaction index":1,"emoji":"my-emoji-1","message":"discord_bot.welcome.configuration_duplicate_emoji_message"}`, parts[1])
 		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
 		require.Empty(t, parts[3])
 	})
@@ -160,7 +201,7 @@ func TestNewWelcomeManager_ErrorHasValidConfigurationInFile(t *testing.T) {
 
 		parts := strings.Split(bufferLogs.String(), "\n")
 		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
-		require.JSONEq(t, `{"level":"error","message index":1,"message":"discord_bot.welcome.configuration_empty_role_message"}`, parts[1])
+		require.JSONEq(t, `{"level":"error","message index":1,"reaction index":0,"message":"discord_bot.welcome.configuration_empty_role_message"}`, parts[1])
 		require.JSONEq(t, `{"level":"error","step":1,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[2])
 		require.Empty(t, parts[3])
 	})
@@ -243,7 +284,7 @@ func TestNewWelcomeManager_ErrorHasValidConfigurationAgainstDiscordServer(t *tes
 		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
 		require.JSONEq(t, `{"level":"info","guild_id":"guild-123","guild":"guild-name","message":"discord_bot.welcome.set_guild_id"}`, parts[1])
 		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.set_channel_id"}`, parts[2])
-		require.JSONEq(t, `{"level":"error","message index":0,"emoji":"my-emoji-1","message":"discord_bot.welcome.configuration_emoji_missed"}`, parts[3])
+		require.JSONEq(t, `{"level":"error","message index":0,"reaction index":0,"emoji":"my-emoji-1","message":"discord_bot.welcome.configuration_emoji_missed"}`, parts[3])
 		require.JSONEq(t, `{"level":"error","step":2,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[4])
 		require.Empty(t, parts[5])
 	})
@@ -275,9 +316,80 @@ func TestNewWelcomeManager_ErrorHasValidConfigurationAgainstDiscordServer(t *tes
 		require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
 		require.JSONEq(t, `{"level":"info","guild_id":"guild-123","guild":"guild-name","message":"discord_bot.welcome.set_guild_id"}`, parts[1])
 		require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.set_channel_id"}`, parts[2])
-		require.JSONEq(t, `{"level":"info","message index":0,"emoji_id":"emoji-123","emoji":"my-emoji-1","message":"discord_bot.welcome.set_emoji_id"}`, parts[3])
-		require.JSONEq(t, `{"level":"error","message index":0,"role":"my role 1","message":"discord_bot.welcome.configuration_role_missed"}`, parts[4])
+		require.JSONEq(t, `{"level":"info","message index":0,"reaction index":0,"emoji_id":"emoji-123","emoji":"my-emoji-1","message":"discord_bot.welcome.set_emoji_id"}`, parts[3])
+		require.JSONEq(t, `{"level":"error","message index":0,"reaction index":0,"role":"my role 1","message":"discord_bot.welcome.configuration_role_missed"}`, parts[4])
 		require.JSONEq(t, `{"level":"error","step":2,"message":"discord_bot.welcome.configuration_validation_failed"}`, parts[5])
 		require.Empty(t, parts[6])
 	})
 }
+
+func TestNewWelcomeManager_UnicodeEmoji(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	session.State.Guilds = append(session.State.Guilds, &discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}},
+	})
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{Title: "my title 1", Emoji: "✅", Role: "my role 1"},
+		},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+	require.JSONEq(t, `{"level":"info","guild_id":"guild-123","guild":"guild-name","message":"discord_bot.welcome.set_guild_id"}`, parts[1])
+	require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.set_channel_id"}`, parts[2])
+	require.JSONEq(t, `{"level":"info","message index":0,"reaction index":0,"role_id":"role-123","role":"my role 1","message":"discord_bot.welcome.set_role_id"}`, parts[3])
+	require.JSONEq(t, `{"level":"info","message index":0,"reaction index":0,"emoji":"✅","message":"discord_bot.welcome.set_unicode_emoji"}`, parts[4])
+	require.JSONEq(t, `{"level":"warn","guild":"guild-name","message":"discord_bot.welcome.permissions_check_skipped"}`, parts[5])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.configuration_validated"}`, parts[6])
+	require.Empty(t, parts[7])
+}
+
+func TestNewWelcomeManager_MultipleReactions(t *testing.T) {
+	var bufferLogs bytes.Buffer
+
+	log.Logger = zerolog.New(&bufferLogs).Level(zerolog.TraceLevel).With().Logger()
+
+	session, err := discordgo.New("fake-token")
+	require.NoError(t, err)
+
+	session.State.Guilds = append(session.State.Guilds, &discordgo.Guild{
+		ID:       "guild-123",
+		Name:     guildName,
+		Channels: []*discordgo.Channel{{ID: "channel-123", Name: "my-channel"}},
+		Emojis:   []*discordgo.Emoji{{ID: "emoji-123", Name: "my-emoji-1"}},
+		Roles:    []*discordgo.Role{{ID: "role-123", Name: "my role 1"}, {ID: "role-456", Name: "my role 2"}},
+	})
+
+	welcomeManager := welcome.NewWelcomeManager(welcome.Configuration{
+		Channel: "my-channel",
+		Messages: []welcome.Message{
+			{Title: "my title 1", Reactions: []welcome.Reaction{{Emoji: "my-emoji-1", Role: "my role 1"}, {Emoji: "✅", Role: "my role 2"}}},
+		},
+	}, guildName, session)
+	require.NotNil(t, welcomeManager)
+
+	parts := strings.Split(bufferLogs.String(), "\n")
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.validating_configuration"}`, parts[0])
+	require.JSONEq(t, `{"level":"info","guild_id":"guild-123","guild":"guild-name","message":"discord_bot.welcome.set_guild_id"}`, parts[1])
+	require.JSONEq(t, `{"level":"info","channel_id":"channel-123","channel":"my-channel","message":"discord_bot.welcome.set_channel_id"}`, parts[2])
+	require.JSONEq(t, `{"level":"info","message index":0,"reaction index":0,"role_id":"role-123","role":"my role 1","message":"discord_bot.welcome.set_role_id"}`, parts[3])
+	require.JSONEq(t, `{"level":"info","message index":0,"reaction index":1,"role_id":"role-456","role":"my role 2","message":"discord_bot.welcome.set_role_id"}`, parts[4])
+	require.JSONEq(t, `{"level":"info","message index":0,"reaction index":0,"emoji_id":"emoji-123","emoji":"my-emoji-1","message":"discord_bot.welcome.set_emoji_id"}`, parts[5])
+	require.JSONEq(t, `{"level":"info","message index":0,"reaction index":1,"emoji":"✅","message":"discord_bot.welcome.set_unicode_emoji"}`, parts[6])
+	require.JSONEq(t, `{"level":"warn","guild":"guild-name","message":"discord_bot.welcome.permissions_check_skipped"}`, parts[7])
+	require.JSONEq(t, `{"level":"info","message":"discord_bot.welcome.configuration_validated"}`, parts[8])
+	require.Empty(t, parts[9])
+}